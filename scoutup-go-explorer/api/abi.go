@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// the contracts of a chain whose ABI can be registered through the API
+const maxRegisteredABIs = 1000
+
+var (
+	errConfiguredABI = errors.New("the ABI is set by the config file")
+	errTooManyABIs   = fmt.Errorf("no more than %d ABIs can be registered", maxRegisteredABIs)
+)
+
+// abiRegistry keeps the parsed ABIs of a chain's contracts, from the config
+// and registered through the API.
+type abiRegistry struct {
+	mu   sync.RWMutex
+	abis map[common.Address]*abi.ABI
+	// the contracts whose ABI comes from the config, which cannot be replaced
+	configured map[common.Address]bool
+	registered int
+}
+
+func newABIRegistry(abis map[common.Address]*abi.ABI) *abiRegistry {
+	r := &abiRegistry{
+		abis:       make(map[common.Address]*abi.ABI, len(abis)),
+		configured: make(map[common.Address]bool, len(abis)),
+	}
+	for address, contract := range abis {
+		r.abis[address] = contract
+		r.configured[address] = true
+	}
+	return r
+}
+
+// register sets the ABI of the contract, replacing the one registered before.
+func (r *abiRegistry) register(address common.Address, contract *abi.ABI) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, replaced := r.abis[address]
+	switch {
+	case r.configured[address]:
+		return errConfiguredABI
+	case !replaced && r.registered >= maxRegisteredABIs:
+		return errTooManyABIs
+	case !replaced:
+		r.registered++
+	}
+	r.abis[address] = contract
+	return nil
+}
+
+func (r *abiRegistry) get(address common.Address) *abi.ABI {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.abis[address]
+}
+
+// decodeInput decodes the calldata of a call to the contract. Overloaded
+// methods differ in their selectors, so the selector alone picks the one
+// matching the arguments. Without a method matching the selector, or when
+// the arguments do not unpack, only the selector is returned.
+func (r *abiRegistry) decodeInput(to *string, input string) *DecodedInput {
+	if to == nil {
+		// contract creation
+		return nil
+	}
+	data, err := hexutil.Decode(input)
+	if err != nil || len(data) < 4 {
+		return nil
+	}
+	decoded := &DecodedInput{Selector: hexutil.Encode(data[:4])}
+
+	contract := r.get(common.HexToAddress(*to))
+	if contract == nil {
+		return decoded
+	}
+	method, err := contract.MethodById(data[:4])
+	if err != nil {
+		return decoded
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return decoded
+	}
+
+	signature := method.Sig
+	decoded.Method = &signature
+	decoded.Params = make([]*DecodedParam, len(args))
+	for i, arg := range args {
+		decoded.Params[i] = &DecodedParam{
+			Name:  method.Inputs[i].Name,
+			Type:  method.Inputs[i].Type.String(),
+			Value: formatValue(reflect.ValueOf(arg)),
+		}
+	}
+	return decoded
+}
+
+// formatValue converts an unpacked argument to its JSON form: integers as
+// decimal strings, addresses checksummed and bytes as hex.
+func formatValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch value := v.Interface().(type) {
+	case *big.Int:
+		return value.String()
+	case common.Address:
+		return value.Hex()
+	case []byte:
+		return hexutil.Encode(value)
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(v.Int()).String()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(v.Uint()).String()
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return hexutil.Encode(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return items
+	case reflect.Struct:
+		// tuples, the fields are tagged with the ABI component names
+		fields := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			fields[name] = formatValue(v.Field(i))
+		}
+		return fields
+	case reflect.Pointer:
+		return formatValue(v.Elem())
+	}
+	return v.Interface()
+}
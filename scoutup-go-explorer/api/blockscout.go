@@ -0,0 +1,440 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/blockscout/scoutup/config"
+	"github.com/blockscout/scoutup/mempool"
+	"github.com/blockscout/scoutup/metrics"
+	"github.com/blockscout/scoutup/rpcclient"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const backendTimeout = 30 * time.Second
+
+var (
+	errNotFound   = errors.New("not found")
+	errNotIndexed = errors.New("no block is indexed yet")
+)
+
+// backend queries the /api/v2 of a chain's Blockscout backend
+// and the chain's RPC for the state not kept by Blockscout.
+type backend struct {
+	chain   *config.ChainConfig
+	log     log.Logger
+	name    string
+	baseURL string
+	client  *http.Client
+	abis    *abiRegistry
+	cache   *cache
+	metrics *metrics.Chain
+	// nil unless the chain's mempool is watched
+	mempool      *mempool.Watcher
+	stopWatching context.CancelFunc
+	finality     finality
+	stats        blockStats
+
+	mu  sync.Mutex
+	rpc *rpcclient.Client
+}
+
+func newBackend(log log.Logger, cfg *config.BlockscoutConfig, cache *cache) *backend {
+	b := &backend{
+		chain:   cfg.ChainConfig,
+		log:     cfg.Logger(log),
+		name:    cfg.Name,
+		baseURL: cfg.BackendURL(),
+		client:  &http.Client{Timeout: backendTimeout},
+		abis:    newABIRegistry(cfg.ContractABIs),
+		cache:   cache,
+		metrics: metrics.ForChain(cfg.ChainID, cfg.Name),
+	}
+	if cfg.WatchMempool {
+		b.mempool = mempool.New(log, cfg)
+	}
+	return b
+}
+
+// get decodes the response of the path into out, errNotFound is returned when
+// Blockscout has not indexed the requested entity (yet).
+func (b *backend) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	u := b.baseURL + "/api/v2" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return b.fetch(ctx, u, out)
+}
+
+// getRPCAPI decodes the response of Blockscout's Etherscan-compatible /api,
+// e.g. for the logs queries /api/v2 has no equivalent of.
+func (b *backend) getRPCAPI(ctx context.Context, query url.Values, out interface{}) error {
+	return b.fetch(ctx, b.baseURL+"/api?"+query.Encode(), out)
+}
+
+func (b *backend) fetch(ctx context.Context, u string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("blockscout of %s is unavailable: %w", b.name, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errNotFound
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("blockscout of %s responded with %s: %s", b.name, resp.Status, body)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	// keeps the numbers of the page params intact
+	decoder.UseNumber()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("cannot decode blockscout response: %w", err)
+	}
+	return nil
+}
+
+type bsAddress struct {
+	Hash string `json:"hash"`
+}
+
+type bsBlock struct {
+	Height           uint64    `json:"height"`
+	Hash             string    `json:"hash"`
+	ParentHash       string    `json:"parent_hash"`
+	Timestamp        time.Time `json:"timestamp"`
+	Miner            bsAddress `json:"miner"`
+	Size             uint64    `json:"size"`
+	GasUsed          string    `json:"gas_used"`
+	GasLimit         string    `json:"gas_limit"`
+	BaseFeePerGas    *string   `json:"base_fee_per_gas"`
+	TransactionCount *uint64   `json:"transaction_count"`
+	WithdrawalsCount *uint64   `json:"withdrawals_count"`
+	// block, or reorg and uncle for the non-consensus ones
+	Type string `json:"type"`
+	// older Blockscout versions
+	TxCount uint64 `json:"tx_count"`
+}
+
+type bsWithdrawal struct {
+	Index          uint64    `json:"index"`
+	ValidatorIndex uint64    `json:"validator_index"`
+	Receiver       bsAddress `json:"receiver"`
+	Amount         string    `json:"amount"`
+}
+
+type bsTransaction struct {
+	Hash        string     `json:"hash"`
+	BlockNumber *uint64    `json:"block_number"`
+	Position    *uint64    `json:"position"`
+	Timestamp   *time.Time `json:"timestamp"`
+	From        bsAddress  `json:"from"`
+	To          *bsAddress `json:"to"`
+	Value       string     `json:"value"`
+	Nonce       uint64     `json:"nonce"`
+	GasLimit    string     `json:"gas_limit"`
+	GasUsed     string     `json:"gas_used"`
+	GasPrice    string     `json:"gas_price"`
+	RawInput    string     `json:"raw_input"`
+	Type        *uint64    `json:"type"`
+	// nil for legacy transactions
+	MaxFeePerGas         *string `json:"max_fee_per_gas"`
+	MaxPriorityFeePerGas *string `json:"max_priority_fee_per_gas"`
+	// ok or error as of the receipt, nil while pending
+	Status *string `json:"status"`
+	// success, or why the transaction failed, e.g. Reverted or out of gas
+	Result       string          `json:"result"`
+	RevertReason json.RawMessage `json:"revert_reason"`
+	// older Blockscout versions
+	Block *uint64 `json:"block"`
+}
+
+type bsInternalTransaction struct {
+	Index           uint64     `json:"index"`
+	Type            string     `json:"type"`
+	CallType        *string    `json:"call_type"`
+	From            bsAddress  `json:"from"`
+	To              *bsAddress `json:"to"`
+	CreatedContract *bsAddress `json:"created_contract"`
+	Value           string     `json:"value"`
+	Success         bool       `json:"success"`
+	Error           *string    `json:"error"`
+}
+
+type bsLog struct {
+	Index   uint64    `json:"index"`
+	Address bsAddress `json:"address"`
+	// padded with nulls up to 4 topics
+	Topics          []*string `json:"topics"`
+	Data            string    `json:"data"`
+	BlockNumber     uint64    `json:"block_number"`
+	TransactionHash string    `json:"transaction_hash"`
+}
+
+type bsToken struct {
+	Name        *string `json:"name"`
+	Symbol      *string `json:"symbol"`
+	Decimals    *string `json:"decimals"`
+	TotalSupply *string `json:"total_supply"`
+	Type        string  `json:"type"`
+}
+
+type bsTokenHolder struct {
+	Address bsAddress `json:"address"`
+	Value   string    `json:"value"`
+}
+
+// bsPage is a page of a Blockscout list, NextPageParams are the query params
+// of the next page, nil on the last one.
+type bsPage[T any] struct {
+	Items          []T                    `json:"items"`
+	NextPageParams map[string]interface{} `json:"next_page_params"`
+}
+
+func (b *backend) block(ctx context.Context, number uint64) (*Block, error) {
+	cached, err := b.cachedBlock(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	block := newBlock(cached.block)
+	block.Finalized = b.finalized(ctx, cached.block)
+	return block, nil
+}
+
+// blockDetail returns the block along with its withdrawals.
+func (b *backend) blockDetail(ctx context.Context, number uint64) (*BlockDetail, error) {
+	cached, err := b.cachedBlock(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if cached.withdrawals == nil {
+		withdrawals, err := b.withdrawals(ctx, cached.block)
+		if err != nil {
+			return nil, err
+		}
+		cached = &cachedBlock{block: cached.block, withdrawals: withdrawals}
+		b.cache.addBlock(b.chain.ChainID, cached)
+	}
+
+	detail := &BlockDetail{Block: newBlock(cached.block), Withdrawals: make([]*Withdrawal, len(cached.withdrawals))}
+	detail.Finalized = b.finalized(ctx, cached.block)
+	for i, withdrawal := range cached.withdrawals {
+		detail.Withdrawals[i] = newWithdrawal(withdrawal)
+	}
+	return detail, nil
+}
+
+func (b *backend) cachedBlock(ctx context.Context, number uint64) (*cachedBlock, error) {
+	cached, ok := b.cache.block(b.chain.ChainID, number)
+	b.metrics.CacheLookup("block", ok)
+	if ok {
+		return cached, nil
+	}
+
+	var block bsBlock
+	if err := b.get(ctx, fmt.Sprintf("/blocks/%d", number), nil, &block); err != nil {
+		return nil, err
+	}
+	cached = &cachedBlock{block: &block}
+	b.cache.addBlock(b.chain.ChainID, cached)
+	return cached, nil
+}
+
+func (b *backend) blockByHash(ctx context.Context, hash string) (*bsBlock, error) {
+	var block bsBlock
+	if err := b.get(ctx, "/blocks/"+hash, nil, &block); err != nil {
+		return nil, err
+	}
+	b.cache.addBlock(b.chain.ChainID, &cachedBlock{block: &block})
+	return &block, nil
+}
+
+func (b *backend) withdrawals(ctx context.Context, block *bsBlock) ([]*bsWithdrawal, error) {
+	withdrawals := []*bsWithdrawal{}
+	if block.WithdrawalsCount != nil && *block.WithdrawalsCount == 0 {
+		// blocks before Shanghai have none
+		return withdrawals, nil
+	}
+
+	var page url.Values
+	for {
+		var resp bsPage[*bsWithdrawal]
+		err := b.get(ctx, fmt.Sprintf("/blocks/%d/withdrawals", block.Height), page, &resp)
+		if errors.Is(err, errNotFound) {
+			// Blockscout versions without withdrawals support
+			return withdrawals, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch withdrawals: %w", err)
+		}
+		withdrawals = append(withdrawals, resp.Items...)
+		if page = pageParams(resp.NextPageParams); page == nil {
+			return withdrawals, nil
+		}
+	}
+}
+
+func (b *backend) transaction(ctx context.Context, hash string) (*Transaction, error) {
+	tx, err := b.cachedTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return b.newTransaction(tx), nil
+}
+
+// transactionDetail returns the transaction along with its revert reason when it failed.
+func (b *backend) transactionDetail(ctx context.Context, hash string) (*Transaction, error) {
+	bs, err := b.cachedTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	tx := b.newTransaction(bs)
+	if tx.Status != nil && *tx.Status == statusFailed {
+		tx.RevertReason = b.revertReason(ctx, bs)
+	}
+	return tx, nil
+}
+
+func (b *backend) cachedTransaction(ctx context.Context, hash string) (*bsTransaction, error) {
+	if tx, ok := b.cache.transaction(b.chain.ChainID, hash); ok {
+		b.metrics.CacheLookup("transaction", true)
+		return tx, nil
+	}
+	b.metrics.CacheLookup("transaction", false)
+
+	var tx bsTransaction
+	if err := b.get(ctx, "/transactions/"+hash, nil, &tx); err != nil {
+		return nil, err
+	}
+	b.cache.addTransaction(b.chain.ChainID, hash, &tx)
+	return &tx, nil
+}
+
+// internalTransactions returns a page of the calls traced within the
+// transaction and the params of the next page, if any.
+func (b *backend) internalTransactions(ctx context.Context, hash string, page url.Values) ([]*InternalTransaction, url.Values, error) {
+	var resp bsPage[*bsInternalTransaction]
+	if err := b.get(ctx, fmt.Sprintf("/transactions/%s/internal-transactions", hash), page, &resp); err != nil {
+		return nil, nil, err
+	}
+	calls := make([]*InternalTransaction, len(resp.Items))
+	for i, call := range resp.Items {
+		calls[i] = newInternalTransaction(call)
+	}
+	return calls, pageParams(resp.NextPageParams), nil
+}
+
+// addressTransactions returns a page of the transactions sent or received by
+// the address and the params of the next page, if any.
+func (b *backend) addressTransactions(ctx context.Context, address common.Address, page url.Values) ([]*Transaction, url.Values, error) {
+	var resp bsPage[*bsTransaction]
+	err := b.get(ctx, fmt.Sprintf("/addresses/%s/transactions", address.Hex()), page, &resp)
+	if errors.Is(err, errNotFound) {
+		// the address has not been seen on chain
+		return []*Transaction{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txs := make([]*Transaction, len(resp.Items))
+	for i, tx := range resp.Items {
+		txs[i] = b.newTransaction(tx)
+	}
+	return txs, pageParams(resp.NextPageParams), nil
+}
+
+// transactionLogs returns all the logs emitted by the transaction.
+func (b *backend) transactionLogs(ctx context.Context, hash string) ([]*Log, error) {
+	logs := []*Log{}
+	var page url.Values
+	for {
+		var resp bsPage[*bsLog]
+		if err := b.get(ctx, "/transactions/"+hash+"/logs", page, &resp); err != nil {
+			return nil, err
+		}
+		for _, log := range resp.Items {
+			logs = append(logs, newLog(log))
+		}
+		if page = pageParams(resp.NextPageParams); page == nil {
+			return logs, nil
+		}
+	}
+}
+
+func pageParams(params map[string]interface{}) url.Values {
+	if params == nil {
+		return nil
+	}
+	values := url.Values{}
+	for key, value := range params {
+		if value != nil {
+			values.Set(key, fmt.Sprint(value))
+		}
+	}
+	return values
+}
+
+// tokenHolders returns the token and a page of its holders ordered by balance,
+// largest first, along with the params of the next page, if any.
+func (b *backend) tokenHolders(ctx context.Context, address common.Address, page url.Values) (*Token, []*TokenHolder, url.Values, error) {
+	token, err := b.token(ctx, address)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var resp bsPage[*bsTokenHolder]
+	if err := b.get(ctx, fmt.Sprintf("/tokens/%s/holders", address.Hex()), page, &resp); err != nil {
+		return nil, nil, nil, err
+	}
+	holders := make([]*TokenHolder, len(resp.Items))
+	for i, holder := range resp.Items {
+		holders[i] = &TokenHolder{Address: checksum(holder.Address.Hash), Balance: holder.Value}
+	}
+	return token, holders, pageParams(resp.NextPageParams), nil
+}
+
+func (b *backend) token(ctx context.Context, address common.Address) (*Token, error) {
+	var token bsToken
+	if err := b.get(ctx, "/tokens/"+address.Hex(), nil, &token); err != nil {
+		return nil, err
+	}
+	return newToken(&token, address), nil
+}
+
+func (b *backend) newTransaction(bs *bsTransaction) *Transaction {
+	tx := newTransaction(bs)
+	tx.DecodedInput = b.abis.decodeInput(tx.To, tx.Input)
+	return tx
+}
+
+// indexedHeight returns the latest block indexed by Blockscout.
+func (b *backend) indexedHeight(ctx context.Context) (uint64, error) {
+	var blocks []*bsBlock
+	if err := b.get(ctx, "/main-page/blocks", nil, &blocks); err != nil {
+		return 0, err
+	}
+	if len(blocks) == 0 {
+		return 0, errNotIndexed
+	}
+	height := uint64(0)
+	for _, block := range blocks {
+		height = max(height, block.Height)
+	}
+	return height, nil
+}
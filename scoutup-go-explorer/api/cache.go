@@ -0,0 +1,101 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+)
+
+type blockKey struct {
+	chainID uint64
+	number  uint64
+}
+
+type txKey struct {
+	chainID uint64
+	hash    string
+}
+
+type cachedBlock struct {
+	block *bsBlock
+	// nil until the block detail is requested
+	withdrawals []*bsWithdrawal
+}
+
+// cache keeps the recently served blocks and transactions of all the chains,
+// so that the hot set of recent blocks is not requested from Blockscout over
+// and over. A nil cache keeps nothing.
+type cache struct {
+	blocks       *lru.Cache[blockKey, *cachedBlock]
+	transactions *lru.Cache[txKey, *bsTransaction]
+}
+
+// newCache returns a cache of size blocks and size transactions, nil when
+// size is not positive.
+func newCache(size int) *cache {
+	if size <= 0 {
+		return nil
+	}
+	return &cache{
+		blocks:       lru.NewCache[blockKey, *cachedBlock](size),
+		transactions: lru.NewCache[txKey, *bsTransaction](size),
+	}
+}
+
+func (c *cache) block(chainID, number uint64) (*cachedBlock, bool) {
+	if c == nil {
+		return nil, false
+	}
+	return c.blocks.Get(blockKey{chainID, number})
+}
+
+func (c *cache) addBlock(chainID uint64, block *cachedBlock) {
+	// blocks orphaned by a reorg are refetched by Blockscout
+	if c == nil || block.block.Type == "reorg" || block.block.Type == "uncle" {
+		return
+	}
+	c.blocks.Add(blockKey{chainID, block.block.Height}, block)
+}
+
+func (c *cache) transaction(chainID uint64, hash string) (*bsTransaction, bool) {
+	if c == nil {
+		return nil, false
+	}
+	return c.transactions.Get(txKey{chainID, strings.ToLower(hash)})
+}
+
+func (c *cache) addTransaction(chainID uint64, hash string, tx *bsTransaction) {
+	// pending transactions are yet to get their receipt
+	if c == nil || txBlockNumber(tx) == nil {
+		return
+	}
+	c.transactions.Add(txKey{chainID, strings.ToLower(hash)}, tx)
+}
+
+// invalidate drops the blocks of the chain at and above from, along with
+// their transactions.
+func (c *cache) invalidate(chainID, from uint64) {
+	if c == nil {
+		return
+	}
+	for _, key := range c.blocks.Keys() {
+		if key.chainID == chainID && key.number >= from {
+			c.blocks.Remove(key)
+		}
+	}
+	for _, key := range c.transactions.Keys() {
+		if key.chainID != chainID {
+			continue
+		}
+		if tx, ok := c.transactions.Peek(key); ok && *txBlockNumber(tx) >= from {
+			c.transactions.Remove(key)
+		}
+	}
+}
+
+func txBlockNumber(tx *bsTransaction) *uint64 {
+	if tx.BlockNumber != nil {
+		return tx.BlockNumber
+	}
+	return tx.Block
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+const (
+	defaultPageLimit = 25
+	// larger limits are clamped to this one
+	maxPageLimit = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// The cursors are opaque to the clients, they encode the block number the
+// next page starts at.
+
+func encodeCursor(number uint64) string {
+	return base64.RawURLEncoding.EncodeToString(binary.BigEndian.AppendUint64(nil, number))
+}
+
+func decodeCursor(cursor string) (uint64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) != 8 {
+		return 0, errInvalidCursor
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// Blockscout lists are paginated with their own params, the cursors of those
+// encode the params of the next page.
+
+func encodePageCursor(params url.Values) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(params.Encode()))
+}
+
+func decodePageCursor(cursor string) (url.Values, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	params, err := url.ParseQuery(string(b))
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return params, nil
+}
+
+// pageLimit parses the limit query param, clamping it to maxPageLimit.
+func pageLimit(query url.Values) (uint64, error) {
+	s := query.Get("limit")
+	if s == "" {
+		return defaultPageLimit, nil
+	}
+	limit, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || limit == 0 {
+		return 0, errors.New("invalid limit")
+	}
+	return min(limit, maxPageLimit), nil
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	exportCSV    = "csv"
+	exportNDJSON = "ndjson"
+)
+
+var exportColumns = []string{
+	"blockNumber", "index", "timestamp", "hash", "from", "to", "value", "nonce",
+	"gas", "gasUsed", "gasPrice", "effectiveGasPrice", "type", "method", "input",
+}
+
+// exportWriter writes the exported transactions in one of the formats.
+type exportWriter interface {
+	write(tx *Transaction) error
+	flush() error
+}
+
+// handleExport streams the transactions of the blocks from..to, in block and
+// index order. The range is clamped to the first block and the indexed tip,
+// only a few blocks are held in memory at a time, and the export stops as
+// soon as the client disconnects.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = exportCSV
+	}
+	if format != exportCSV && format != exportNDJSON {
+		writeError(w, http.StatusBadRequest, "invalid format, expected csv or ndjson")
+		return
+	}
+	from, err := strconv.ParseUint(query.Get("from"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid or missing from")
+		return
+	}
+	to, err := strconv.ParseUint(query.Get("to"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid or missing to")
+		return
+	}
+	if to < from {
+		writeError(w, http.StatusBadRequest, "to must not be lower than from")
+		return
+	}
+	// the blocks past the tip would only be looked up in vain
+	tip, err := chain.indexedHeight(r.Context())
+	if errors.Is(err, errNotIndexed) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.writeBackendError(w, err, "")
+		return
+	}
+	from, to = max(from, chain.chain.FirstBlock), min(to, tip)
+	if to < from {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no block of the range is indexed, the indexed tip is %d", tip))
+		return
+	}
+
+	var out exportWriter
+	filename := fmt.Sprintf("chain-%d-transactions-%d-%d.%s", chain.chain.ChainID, from, to, format)
+	if format == exportCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		out = newCSVExport(w)
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		out = &ndjsonExport{encoder: json.NewEncoder(w)}
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	err = chain.exportTransactions(r.Context(), from, to, func(txs []*Transaction) error {
+		for _, tx := range txs {
+			if err := out.write(tx); err != nil {
+				return err
+			}
+		}
+		if err := out.flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = out.flush()
+	}
+	if err != nil {
+		if r.Context().Err() == nil {
+			chain.log.Warn("Export failed", "from", from, "to", to, "err", err)
+		}
+		// the status is already sent, aborting the response tells the client
+		// that the export is incomplete
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportTransactions passes the transactions of every block from..to to write,
+// block after block. A block that is not indexed fails the export, which would
+// be incomplete otherwise.
+func (b *backend) exportTransactions(ctx context.Context, from, to uint64, write func([]*Transaction) error) error {
+	for start := from; start <= to; start += blockPageConcurrency {
+		end := min(to, start+blockPageConcurrency-1)
+		blocks := make([][]*Transaction, end-start+1)
+		errs := make([]error, len(blocks))
+		var wg sync.WaitGroup
+		for i := range blocks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				blocks[i], errs[i] = b.blockTransactions(ctx, start+uint64(i))
+			}()
+		}
+		wg.Wait()
+
+		for i, txs := range blocks {
+			switch err := errs[i]; {
+			case errors.Is(err, errNotFound):
+				return fmt.Errorf("block %d is not indexed", start+uint64(i))
+			case err != nil:
+				return err
+			}
+			if err := write(txs); err != nil {
+				return err
+			}
+		}
+		if end == to {
+			break
+		}
+	}
+	return nil
+}
+
+// blockTransactions returns all the transactions of the block, in index order.
+func (b *backend) blockTransactions(ctx context.Context, number uint64) ([]*Transaction, error) {
+	var txs []*Transaction
+	var page url.Values
+	for {
+		var resp bsPage[*bsTransaction]
+		if err := b.get(ctx, fmt.Sprintf("/blocks/%d/transactions", number), page, &resp); err != nil {
+			return nil, err
+		}
+		for _, tx := range resp.Items {
+			txs = append(txs, b.newTransaction(tx))
+		}
+		page = pageParams(resp.NextPageParams)
+		if page == nil {
+			break
+		}
+	}
+	slices.SortFunc(txs, func(a, b *Transaction) int {
+		return compareOptional(a.Index, b.Index)
+	})
+	return txs, nil
+}
+
+func compareOptional(a, b *uint64) int {
+	switch {
+	case a == nil || b == nil:
+		return 0
+	case *a < *b:
+		return -1
+	case *a > *b:
+		return 1
+	}
+	return 0
+}
+
+type ndjsonExport struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExport) write(tx *Transaction) error {
+	return e.encoder.Encode(tx)
+}
+
+func (e *ndjsonExport) flush() error {
+	return nil
+}
+
+type csvExport struct {
+	writer *csv.Writer
+}
+
+// newCSVExport returns the export with the header row written, its error, if
+// any, shows up on flush.
+func newCSVExport(w io.Writer) *csvExport {
+	writer := csv.NewWriter(w)
+	_ = writer.Write(exportColumns)
+	return &csvExport{writer: writer}
+}
+
+func (e *csvExport) write(tx *Transaction) error {
+	var method string
+	if tx.DecodedInput != nil && tx.DecodedInput.Method != nil {
+		method = *tx.DecodedInput.Method
+	}
+	return e.writer.Write([]string{
+		optionalUint(tx.BlockNumber),
+		optionalUint(tx.Index),
+		optionalTime(tx.Timestamp),
+		tx.Hash,
+		tx.From,
+		optionalString(tx.To),
+		tx.Value,
+		strconv.FormatUint(tx.Nonce, 10),
+		tx.Gas,
+		tx.GasUsed,
+		tx.GasPrice,
+		optionalString(tx.EffectiveGasPrice),
+		optionalUint(tx.Type),
+		method,
+		tx.Input,
+	})
+}
+
+func (e *csvExport) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func optionalUint(n *uint64) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatUint(*n, 10)
+}
+
+func optionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
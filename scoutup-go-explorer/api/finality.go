@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// the finalized height is shared by the requests made within this interval
+const finalityTTL = 2 * time.Second
+
+// finality caches the finalized height of a chain.
+type finality struct {
+	mu        sync.Mutex
+	height    uint64
+	final     bool
+	fetchedAt time.Time
+}
+
+// finalized reports whether the block can no longer be reorged, i.e. it is
+// canonical and at or below the finalized height. A block is reported as not
+// finalized when the height cannot be determined.
+func (b *backend) finalized(ctx context.Context, block *bsBlock) bool {
+	if block.Type != "" && block.Type != "block" {
+		return false
+	}
+	height, ok, err := b.finalizedHeight(ctx)
+	if err != nil {
+		b.log.Debug("Cannot get the finalized block", "err", err)
+		return false
+	}
+	return ok && block.Height <= height
+}
+
+// finalizedHeight returns the node's finalized block when it has one, falling
+// back to the head minus Confirmations otherwise. ok is false while no block
+// is final yet.
+func (b *backend) finalizedHeight(ctx context.Context) (height uint64, ok bool, err error) {
+	b.finality.mu.Lock()
+	defer b.finality.mu.Unlock()
+	if time.Since(b.finality.fetchedAt) < finalityTTL {
+		return b.finality.height, b.finality.final, nil
+	}
+
+	client, err := b.node(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	var finalized *struct {
+		Number hexutil.Uint64 `json:"number"`
+	}
+	if err := client.CallContext(ctx, &finalized, "eth_getBlockByNumber", "finalized", false); err == nil && finalized != nil {
+		height, ok = uint64(finalized.Number), true
+	} else {
+		// the node predates the finalized tag or has no finalized block
+		var head hexutil.Uint64
+		if err := client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+			return 0, false, err
+		}
+		confirmations := b.chain.Confirmations
+		height, ok = uint64(head)-min(uint64(head), confirmations), uint64(head) >= confirmations
+	}
+
+	b.finality.height, b.finality.final, b.finality.fetchedAt = height, ok, time.Now()
+	return height, ok, nil
+}
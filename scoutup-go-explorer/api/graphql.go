@@ -0,0 +1,1016 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// A small GraphQL implementation covering the queries: selections with
+// aliases and arguments, variables, fragments and inline fragments. Mutations,
+// subscriptions, directives and introspection are not supported.
+
+const (
+	maxGraphQLQuerySize  = 64 << 10
+	maxGraphQLDepth      = 8
+	maxGraphQLComplexity = 5000
+	// the size lists are assumed to have when computing the complexity
+	graphQLListCost = 25
+)
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type graphQLResponse struct {
+	Data   interface{}     `json:"data,omitempty"`
+	Errors []*graphQLError `json:"errors,omitempty"`
+}
+
+type graphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// handleGraphQL serves the GraphQL queries of the chains, read from the JSON
+// body of POST requests or from the query, operationName and variables params
+// of GET requests. Every root field picks its chain with a chainId argument.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := decodeJSONNumbers(strings.NewReader(variables), &req.Variables); err != nil {
+				writeGraphQLError(w, http.StatusBadRequest, "invalid variables: "+err.Error())
+				return
+			}
+		}
+	} else if err := decodeJSONNumbers(http.MaxBytesReader(w, r.Body, maxGraphQLQuerySize), &req); err != nil {
+		writeGraphQLError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if len(req.Query) > maxGraphQLQuerySize {
+		writeGraphQLError(w, http.StatusBadRequest, "query is too large")
+		return
+	}
+
+	exec, err := newGraphQLExecution(r.Context(), s.lookup, &req)
+	if err != nil {
+		writeGraphQLError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, exec.run())
+}
+
+func writeGraphQLError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, &graphQLResponse{Errors: []*graphQLError{{Message: msg}}})
+}
+
+func decodeJSONNumbers(r io.Reader, out interface{}) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return decoder.Decode(out)
+}
+
+// Query documents
+
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string]*gqlFragment
+}
+
+type gqlOperation struct {
+	name       string
+	variables  []*gqlVariableDef
+	selections []*gqlSelection
+}
+
+type gqlVariableDef struct {
+	name     string
+	nonNull  bool
+	defValue gqlValue
+}
+
+type gqlFragment struct {
+	typeCondition string
+	selections    []*gqlSelection
+}
+
+// gqlSelection is a field, a fragment spread when fragment is set, or an
+// inline fragment when inline is set.
+type gqlSelection struct {
+	alias, name string
+	args        map[string]gqlValue
+	selections  []*gqlSelection
+
+	fragment      string
+	inline        bool
+	typeCondition string
+}
+
+func (s *gqlSelection) key() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// gqlValue is a literal: nil, bool, string, json.Number, gqlEnum,
+// []gqlValue, map[string]gqlValue, or a gqlVariable.
+type gqlValue interface{}
+
+type (
+	gqlVariable string
+	gqlEnum     string
+)
+
+type gqlToken struct {
+	kind  byte
+	value string
+	pos   int
+}
+
+func (t gqlToken) String() string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.value)
+}
+
+const (
+	tokEOF    = 0
+	tokPunct  = 'p'
+	tokName   = 'n'
+	tokNumber = '1'
+	tokString = 's'
+)
+
+type gqlParser struct {
+	src    string
+	pos    int
+	token  gqlToken
+	peeked bool
+}
+
+func parseGraphQL(src string) (*gqlDocument, error) {
+	p := &gqlParser{src: src}
+	doc := &gqlDocument{fragments: map[string]*gqlFragment{}}
+	for {
+		tok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if tok.kind == tokEOF {
+			break
+		}
+		switch {
+		case tok.kind == tokName && tok.value == "fragment":
+			p.next()
+			name, fragment, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[name]; ok {
+				return nil, fmt.Errorf("fragment %q is defined more than once", name)
+			}
+			doc.fragments[name] = fragment
+		default:
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, errors.New("the document has no operation")
+	}
+	return doc, nil
+}
+
+func (p *gqlParser) parseOperation() (*gqlOperation, error) {
+	op := &gqlOperation{}
+	tok, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if tok.kind == tokName {
+		p.next()
+		if tok.value != "query" {
+			return nil, fmt.Errorf("%s operations are not supported", tok.value)
+		}
+		if tok, err = p.peek(); err != nil {
+			return nil, err
+		}
+		if tok.kind == tokName {
+			p.next()
+			op.name = tok.value
+		}
+		if p.isPunct("(") {
+			p.next()
+			for !p.isPunct(")") {
+				def, err := p.parseVariableDef()
+				if err != nil {
+					return nil, err
+				}
+				op.variables = append(op.variables, def)
+			}
+			p.next()
+		}
+	}
+	if op.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *gqlParser) parseVariableDef() (*gqlVariableDef, error) {
+	if err := p.expectPunct("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(":"); err != nil {
+		return nil, err
+	}
+	def := &gqlVariableDef{name: name}
+	if def.nonNull, err = p.parseType(); err != nil {
+		return nil, err
+	}
+	if p.isPunct("=") {
+		p.next()
+		if def.defValue, err = p.parseValue(true); err != nil {
+			return nil, err
+		}
+	}
+	return def, nil
+}
+
+// parseType skips the type of a variable, which is checked by the field arguments
+// instead, and returns whether it is non-null.
+func (p *gqlParser) parseType() (bool, error) {
+	if p.isPunct("[") {
+		p.next()
+		if _, err := p.parseType(); err != nil {
+			return false, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.expectName(); err != nil {
+		return false, err
+	}
+	if p.isPunct("!") {
+		p.next()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (p *gqlParser) parseFragment() (string, *gqlFragment, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return "", nil, err
+	}
+	if on, err := p.expectName(); err != nil || on != "on" {
+		return "", nil, p.errorf("expected on after fragment %s", name)
+	}
+	fragment := &gqlFragment{}
+	if fragment.typeCondition, err = p.expectName(); err != nil {
+		return "", nil, err
+	}
+	if fragment.selections, err = p.parseSelectionSet(); err != nil {
+		return "", nil, err
+	}
+	return name, fragment, nil
+}
+
+func (p *gqlParser) parseSelectionSet() ([]*gqlSelection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var selections []*gqlSelection
+	for !p.isPunct("}") {
+		selection, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	p.next()
+	if len(selections) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+	return selections, nil
+}
+
+func (p *gqlParser) parseSelection() (*gqlSelection, error) {
+	if p.isPunct("@") {
+		return nil, p.errorf("directives are not supported")
+	}
+	if p.isPunct("...") {
+		p.next()
+		tok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		selection := &gqlSelection{inline: true}
+		switch {
+		case tok.kind == tokName && tok.value == "on":
+			p.next()
+			if selection.typeCondition, err = p.expectName(); err != nil {
+				return nil, err
+			}
+		case tok.kind == tokName:
+			p.next()
+			return &gqlSelection{fragment: tok.value}, nil
+		}
+		if selection.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+		return selection, nil
+	}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	selection := &gqlSelection{name: name}
+	if p.isPunct(":") {
+		p.next()
+		selection.alias = name
+		if selection.name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isPunct("(") {
+		p.next()
+		selection.args = map[string]gqlValue{}
+		for !p.isPunct(")") {
+			arg, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(":"); err != nil {
+				return nil, err
+			}
+			if selection.args[arg], err = p.parseValue(false); err != nil {
+				return nil, err
+			}
+		}
+		p.next()
+	}
+	if p.isPunct("@") {
+		return nil, p.errorf("directives are not supported")
+	}
+	if p.isPunct("{") {
+		if selection.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return selection, nil
+}
+
+// parseValue parses a literal, constant ones are the default values of the variables.
+func (p *gqlParser) parseValue(constant bool) (gqlValue, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.kind {
+	case tokNumber:
+		return json.Number(tok.value), nil
+	case tokString:
+		return tok.value, nil
+	case tokName:
+		switch tok.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return gqlEnum(tok.value), nil
+	}
+	switch tok.value {
+	case "$":
+		if constant {
+			return nil, p.errorf("unexpected variable in a default value")
+		}
+		name, err := p.expectName()
+		return gqlVariable(name), err
+	case "[":
+		list := []gqlValue{}
+		for !p.isPunct("]") {
+			value, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		p.next()
+		return list, nil
+	case "{":
+		object := map[string]gqlValue{}
+		for !p.isPunct("}") {
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.parseValue(constant); err != nil {
+				return nil, err
+			}
+		}
+		p.next()
+		return object, nil
+	}
+	return nil, p.errorf("unexpected %s", tok)
+}
+
+func (p *gqlParser) isPunct(value string) bool {
+	tok, err := p.peek()
+	return err == nil && tok.kind == tokPunct && tok.value == value
+}
+
+func (p *gqlParser) expectPunct(value string) error {
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+	if tok.kind != tokPunct || tok.value != value {
+		return p.errorf("expected %q, got %s", value, tok)
+	}
+	return nil
+}
+
+func (p *gqlParser) expectName() (string, error) {
+	tok, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if tok.kind != tokName {
+		return "", p.errorf("expected a name, got %s", tok)
+	}
+	return tok.value, nil
+}
+
+func (p *gqlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at %d: %s", p.token.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *gqlParser) peek() (gqlToken, error) {
+	if !p.peeked {
+		tok, err := p.lex()
+		if err != nil {
+			return gqlToken{}, err
+		}
+		p.token, p.peeked = tok, true
+	}
+	return p.token, nil
+}
+
+func (p *gqlParser) next() (gqlToken, error) {
+	tok, err := p.peek()
+	p.peeked = false
+	return tok, err
+}
+
+func (p *gqlParser) lex() (gqlToken, error) {
+	// whitespace, commas and comments are insignificant
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+			break
+		}
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		return gqlToken{kind: tokEOF, pos: start}, nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		return gqlToken{kind: tokPunct, value: "...", pos: start}, nil
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		p.pos++
+		return gqlToken{kind: tokPunct, value: string(c), pos: start}, nil
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		return gqlToken{kind: tokName, value: p.src[start:p.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		number := p.src[start:p.pos]
+		if _, err := strconv.ParseFloat(number, 64); err != nil {
+			return gqlToken{}, fmt.Errorf("syntax error at %d: invalid number %q", start, number)
+		}
+		return gqlToken{kind: tokNumber, value: number, pos: start}, nil
+	case c == '"':
+		value, err := p.lexString()
+		return gqlToken{kind: tokString, value: value, pos: start}, err
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return gqlToken{}, fmt.Errorf("syntax error at %d: unexpected character %q", start, r)
+}
+
+// lexString reads a quoted string, block strings are not supported.
+func (p *gqlParser) lexString() (string, error) {
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\n', '\r':
+			return "", fmt.Errorf("syntax error at %d: unterminated string", start)
+		case '\\':
+			if p.pos+1 >= len(p.src) {
+				return "", fmt.Errorf("syntax error at %d: unterminated string", start)
+			}
+			escape := p.src[p.pos+1]
+			p.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					return "", fmt.Errorf("syntax error at %d: invalid unicode escape", p.pos)
+				}
+				code, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 16)
+				if err != nil {
+					return "", fmt.Errorf("syntax error at %d: invalid unicode escape", p.pos)
+				}
+				b.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				return "", fmt.Errorf("syntax error at %d: invalid escape \\%c", p.pos-1, escape)
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("syntax error at %d: unterminated string", start)
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Execution
+
+// gqlType is an object type of the schema.
+type gqlType struct {
+	name   string
+	fields map[string]*gqlFieldDef
+}
+
+type gqlFieldDef struct {
+	// nil for scalars
+	typ  *gqlType
+	list bool
+	args []string
+	// resolve returns the value of the field of parent, nil or an
+	// []interface{} for the lists
+	resolve func(ctx context.Context, b *backend, parent interface{}, args map[string]interface{}) (interface{}, error)
+}
+
+type gqlExecution struct {
+	ctx context.Context
+	// returns the backend of the chain picked by a root field
+	lookup    func(chainID uint64) (*backend, bool)
+	operation *gqlOperation
+	fragments map[string]*gqlFragment
+	variables map[string]interface{}
+
+	mu     sync.Mutex
+	errors []*graphQLError
+}
+
+// newGraphQLExecution parses and validates the request, rejecting the queries
+// nested deeper than maxGraphQLDepth or more complex than maxGraphQLComplexity
+// before anything is fetched.
+func newGraphQLExecution(ctx context.Context, lookup func(chainID uint64) (*backend, bool), req *graphQLRequest) (*gqlExecution, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, errors.New("missing query")
+	}
+	doc, err := parseGraphQL(req.Query)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFragmentCycles(doc.fragments); err != nil {
+		return nil, err
+	}
+
+	var operation *gqlOperation
+	for _, op := range doc.operations {
+		if req.OperationName == "" || op.name == req.OperationName {
+			if operation != nil {
+				return nil, errors.New("the document has several operations, operationName is required")
+			}
+			operation = op
+		}
+	}
+	if operation == nil {
+		return nil, fmt.Errorf("unknown operation %q", req.OperationName)
+	}
+
+	exec := &gqlExecution{
+		ctx:       ctx,
+		lookup:    lookup,
+		operation: operation,
+		fragments: doc.fragments,
+		variables: map[string]interface{}{},
+	}
+	for _, def := range operation.variables {
+		value, ok := req.Variables[def.name]
+		if !ok {
+			value = literalValue(def.defValue, nil)
+		}
+		if value == nil && def.nonNull {
+			return nil, fmt.Errorf("variable $%s is required", def.name)
+		}
+		exec.variables[def.name] = value
+	}
+
+	complexity, err := exec.validate(operation.selections, queryType, 1, nil)
+	if err != nil {
+		return nil, err
+	}
+	if complexity > maxGraphQLComplexity {
+		return nil, fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, maxGraphQLComplexity)
+	}
+	return exec, nil
+}
+
+// validate checks the selections against the type and returns their complexity,
+// every field costs 1 and the fields of the list items graphQLListCost times as much.
+func (e *gqlExecution) validate(selections []*gqlSelection, typ *gqlType, depth int, visiting map[string]bool) (int, error) {
+	if depth > maxGraphQLDepth {
+		return 0, fmt.Errorf("query depth exceeds the limit of %d", maxGraphQLDepth)
+	}
+	fields, err := e.collectFields(selections, typ, visiting)
+	if err != nil {
+		return 0, err
+	}
+
+	complexity := 0
+	for _, field := range fields {
+		if field.name == "__typename" {
+			continue
+		}
+		def, ok := typ.fields[field.name]
+		if !ok {
+			return 0, fmt.Errorf("cannot query field %q on type %s", field.name, typ.name)
+		}
+		for arg := range field.args {
+			if !slices.Contains(def.args, arg) {
+				return 0, fmt.Errorf("unknown argument %q of field %s.%s", arg, typ.name, field.name)
+			}
+		}
+		for _, arg := range field.args {
+			if err := e.checkVariables(arg); err != nil {
+				return 0, err
+			}
+		}
+
+		cost := 1
+		switch {
+		case def.typ == nil && len(field.selections) > 0:
+			return 0, fmt.Errorf("field %s.%s has no subfields", typ.name, field.name)
+		case def.typ != nil && len(field.selections) == 0:
+			return 0, fmt.Errorf("field %s.%s of type %s needs a selection of subfields", typ.name, field.name, def.typ.name)
+		case def.typ != nil:
+			nested, err := e.validate(field.selections, def.typ, depth+1, visiting)
+			if err != nil {
+				return 0, err
+			}
+			if def.list {
+				nested *= graphQLListCost
+			}
+			cost += nested
+		}
+		complexity += cost
+		if complexity > maxGraphQLComplexity {
+			// no need to walk the rest
+			return complexity, nil
+		}
+	}
+	return complexity, nil
+}
+
+// checkFragmentCycles rejects the fragments spreading themselves, directly or
+// through other fragments, at any depth of their selections.
+func checkFragmentCycles(fragments map[string]*gqlFragment) error {
+	const visiting, visited = 1, 2
+	state := map[string]int{}
+	var visit func(name string) error
+	var spreads func(selections []*gqlSelection) error
+	visit = func(name string) error {
+		fragment, ok := fragments[name]
+		switch {
+		case !ok || state[name] == visited:
+			// unknown fragments are reported along with the fields
+			return nil
+		case state[name] == visiting:
+			return fmt.Errorf("fragment %q spreads itself", name)
+		}
+		state[name] = visiting
+		if err := spreads(fragment.selections); err != nil {
+			return err
+		}
+		state[name] = visited
+		return nil
+	}
+	spreads = func(selections []*gqlSelection) error {
+		for _, selection := range selections {
+			if selection.fragment != "" {
+				if err := visit(selection.fragment); err != nil {
+					return err
+				}
+			}
+			if err := spreads(selection.selections); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(fragments)) {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *gqlExecution) checkVariables(value gqlValue) error {
+	switch value := value.(type) {
+	case gqlVariable:
+		if _, ok := e.variables[string(value)]; !ok {
+			return fmt.Errorf("variable $%s is not defined", value)
+		}
+	case []gqlValue:
+		for _, item := range value {
+			if err := e.checkVariables(item); err != nil {
+				return err
+			}
+		}
+	case map[string]gqlValue:
+		for _, item := range value {
+			if err := e.checkVariables(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectFields flattens the fragments of the selections applying to the type
+// and merges the fields with the same response key.
+func (e *gqlExecution) collectFields(selections []*gqlSelection, typ *gqlType, visiting map[string]bool) ([]*gqlSelection, error) {
+	var fields []*gqlSelection
+	byKey := map[string]*gqlSelection{}
+	var collect func(selections []*gqlSelection) error
+	collect = func(selections []*gqlSelection) error {
+		for _, selection := range selections {
+			switch {
+			case selection.fragment != "":
+				fragment, ok := e.fragments[selection.fragment]
+				if !ok {
+					return fmt.Errorf("unknown fragment %q", selection.fragment)
+				}
+				if visiting[selection.fragment] {
+					return fmt.Errorf("fragment %q spreads itself", selection.fragment)
+				}
+				if fragment.typeCondition != typ.name {
+					continue
+				}
+				if visiting == nil {
+					visiting = map[string]bool{}
+				}
+				visiting[selection.fragment] = true
+				err := collect(fragment.selections)
+				delete(visiting, selection.fragment)
+				if err != nil {
+					return err
+				}
+			case selection.inline:
+				if selection.typeCondition != "" && selection.typeCondition != typ.name {
+					continue
+				}
+				if err := collect(selection.selections); err != nil {
+					return err
+				}
+			default:
+				if field, ok := byKey[selection.key()]; ok {
+					if field.name != selection.name {
+						return fmt.Errorf("fields %q and %q conflict as %q", field.name, selection.name, selection.key())
+					}
+					field.selections = append(append([]*gqlSelection{}, field.selections...), selection.selections...)
+					continue
+				}
+				field := *selection
+				byKey[selection.key()] = &field
+				fields = append(fields, &field)
+			}
+		}
+		return nil
+	}
+	err := collect(selections)
+	return fields, err
+}
+
+func (e *gqlExecution) run() *graphQLResponse {
+	data := e.executeObject(nil, nil, queryType, e.operation.selections, nil)
+	return &graphQLResponse{Data: data, Errors: e.errors}
+}
+
+// executeObject resolves the selections of parent on the backend of its chain,
+// nil for the root fields which pick their own.
+func (e *gqlExecution) executeObject(parent interface{}, b *backend, typ *gqlType, selections []*gqlSelection, path []interface{}) gqlObject {
+	// validated already
+	fields, _ := e.collectFields(selections, typ, nil)
+	object := make(gqlObject, 0, len(fields))
+	for _, field := range fields {
+		object = append(object, gqlEntry{key: field.key(), value: e.executeField(parent, b, typ, field, appendPath(path, field.key()))})
+	}
+	return object
+}
+
+func (e *gqlExecution) executeField(parent interface{}, b *backend, typ *gqlType, field *gqlSelection, path []interface{}) interface{} {
+	if field.name == "__typename" {
+		return typ.name
+	}
+	def := typ.fields[field.name]
+	args := make(map[string]interface{}, len(field.args))
+	for name, arg := range field.args {
+		args[name] = literalValue(arg, e.variables)
+	}
+	if typ == queryType {
+		var err error
+		if b, err = e.chain(args); err != nil {
+			e.addError(err, path)
+			return nil
+		}
+	}
+
+	value, err := def.resolve(e.ctx, b, parent, args)
+	if errors.Is(err, errNotFound) {
+		return nil
+	}
+	if err != nil {
+		e.addError(err, path)
+		return nil
+	}
+	if value == nil || def.typ == nil {
+		return value
+	}
+	if !def.list {
+		return e.executeObject(value, b, def.typ, field.selections, path)
+	}
+
+	// the items are resolved concurrently, their nested fields may need
+	// requests of their own
+	items := value.([]interface{})
+	results := make([]interface{}, len(items))
+	sem := make(chan struct{}, blockPageConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = e.executeObject(item, b, def.typ, field.selections, appendPath(path, i))
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// chain returns the backend of the chainId argument of a root field.
+func (e *gqlExecution) chain(args map[string]interface{}) (*backend, error) {
+	chainID, ok, err := uintArg(args, "chainId")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New(`argument "chainId" is required`)
+	}
+	b, ok := e.lookup(chainID)
+	if !ok {
+		return nil, fmt.Errorf("unknown chain %d", chainID)
+	}
+	return b, nil
+}
+
+func (e *gqlExecution) addError(err error, path []interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errors = append(e.errors, &graphQLError{Message: err.Error(), Path: path})
+}
+
+func appendPath(path []interface{}, element interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(path)+1), path...), element)
+}
+
+// literalValue resolves the variables of the literal, the JSON values of the
+// variables and the literals end up as the same Go types.
+func literalValue(value gqlValue, variables map[string]interface{}) interface{} {
+	switch value := value.(type) {
+	case gqlVariable:
+		return variables[string(value)]
+	case gqlEnum:
+		return string(value)
+	case []gqlValue:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = literalValue(item, variables)
+		}
+		return list
+	case map[string]gqlValue:
+		object := make(map[string]interface{}, len(value))
+		for name, item := range value {
+			object[name] = literalValue(item, variables)
+		}
+		return object
+	}
+	return value
+}
+
+// gqlObject is a result object, keeping the fields in the order of the query.
+type gqlObject []gqlEntry
+
+type gqlEntry struct {
+	key   string
+	value interface{}
+}
+
+func (o gqlObject) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, entry := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(entry.key)
+		b.Write(key)
+		b.WriteByte(':')
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,303 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// The GraphQL schema, numbers that may not fit in an Int are strings:
+//
+//	type Query {
+//	  block(chainId: Long!, number: Long, hash: String): Block
+//	  transaction(chainId: Long!, hash: String!): Transaction
+//	  account(chainId: Long!, address: String!): Account
+//	}
+//	type Block {
+//	  number: Long, hash: String, parent: Block, timestamp: String, miner: Account,
+//	  size: Long, gasUsed: String, gasLimit: String, baseFeePerGas: String,
+//	  transactionCount: Long, transactions: [Transaction], finalized: Boolean
+//	}
+//	type Transaction {
+//	  hash: String, index: Long, blockNumber: Long, block: Block, timestamp: String,
+//	  from: Account, to: Account, value: String, nonce: Long, gas: String, gasUsed: String,
+//	  gasPrice: String, effectiveGasPrice: String, maxFeePerGas: String,
+//	  maxPriorityFeePerGas: String, type: Long, input: String, status: String, logs: [Log]
+//	}
+//	type Log {
+//	  index: Long, account: Account, topics: [String], data: String, transaction: Transaction
+//	}
+//	type Account {
+//	  address: String, balance(block: Long): String, transactionCount(block: Long): Long,
+//	  code(block: Long): String
+//	}
+//
+// The state of an account is taken at the latest block unless one is given.
+// The nested fields are resolved on the chain picked by their root field.
+
+var (
+	queryType       = &gqlType{name: "Query"}
+	blockType       = &gqlType{name: "Block"}
+	transactionType = &gqlType{name: "Transaction"}
+	logType         = &gqlType{name: "Log"}
+	accountType     = &gqlType{name: "Account"}
+)
+
+// gqlAccount is the parent of the Account fields.
+type gqlAccount struct {
+	address common.Address
+}
+
+func init() {
+	queryType.fields = map[string]*gqlFieldDef{
+		"block": {typ: blockType, args: []string{"chainId", "number", "hash"}, resolve: resolveBlock},
+		"transaction": {typ: transactionType, args: []string{"chainId", "hash"}, resolve: func(ctx context.Context, b *backend, _ interface{}, args map[string]interface{}) (interface{}, error) {
+			hash, err := stringArg(args, "hash", true)
+			if err != nil {
+				return nil, err
+			}
+			if !txHashRegex.MatchString(hash) {
+				return nil, errors.New("invalid transaction hash")
+			}
+			return nilIfNotFound(b.transaction(ctx, hash))
+		}},
+		"account": {typ: accountType, args: []string{"chainId", "address"}, resolve: func(ctx context.Context, b *backend, _ interface{}, args map[string]interface{}) (interface{}, error) {
+			address, err := stringArg(args, "address", true)
+			if err != nil {
+				return nil, err
+			}
+			if !common.IsHexAddress(address) {
+				return nil, errors.New("invalid address")
+			}
+			return &gqlAccount{address: common.HexToAddress(address)}, nil
+		}},
+	}
+
+	blockType.fields = map[string]*gqlFieldDef{
+		"number":           blockScalar(func(block *Block) interface{} { return block.Number }),
+		"hash":             blockScalar(func(block *Block) interface{} { return block.Hash }),
+		"timestamp":        blockScalar(func(block *Block) interface{} { return block.Timestamp }),
+		"size":             blockScalar(func(block *Block) interface{} { return block.Size }),
+		"gasUsed":          blockScalar(func(block *Block) interface{} { return block.GasUsed }),
+		"gasLimit":         blockScalar(func(block *Block) interface{} { return block.GasLimit }),
+		"baseFeePerGas":    blockScalar(func(block *Block) interface{} { return block.BaseFeePerGas }),
+		"transactionCount": blockScalar(func(block *Block) interface{} { return block.TransactionCount }),
+		"finalized":        blockScalar(func(block *Block) interface{} { return block.Finalized }),
+		"parent": {typ: blockType, resolve: func(ctx context.Context, b *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			block := parent.(*Block)
+			if block.Number == 0 {
+				return nil, nil
+			}
+			return nilIfNotFound(b.block(ctx, block.Number-1))
+		}},
+		"miner": {typ: accountType, resolve: func(_ context.Context, _ *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return &gqlAccount{address: common.HexToAddress(parent.(*Block).Miner)}, nil
+		}},
+		"transactions": {typ: transactionType, list: true, resolve: func(ctx context.Context, b *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			txs, err := b.blockTransactions(ctx, parent.(*Block).Number)
+			if err != nil {
+				return nil, err
+			}
+			items := make([]interface{}, len(txs))
+			for i, tx := range txs {
+				items[i] = tx
+			}
+			return items, nil
+		}},
+	}
+
+	transactionType.fields = map[string]*gqlFieldDef{
+		"hash":                 txScalar(func(tx *Transaction) interface{} { return tx.Hash }),
+		"index":                txScalar(func(tx *Transaction) interface{} { return tx.Index }),
+		"blockNumber":          txScalar(func(tx *Transaction) interface{} { return tx.BlockNumber }),
+		"timestamp":            txScalar(func(tx *Transaction) interface{} { return tx.Timestamp }),
+		"value":                txScalar(func(tx *Transaction) interface{} { return tx.Value }),
+		"nonce":                txScalar(func(tx *Transaction) interface{} { return tx.Nonce }),
+		"gas":                  txScalar(func(tx *Transaction) interface{} { return tx.Gas }),
+		"gasUsed":              txScalar(func(tx *Transaction) interface{} { return tx.GasUsed }),
+		"gasPrice":             txScalar(func(tx *Transaction) interface{} { return tx.GasPrice }),
+		"effectiveGasPrice":    txScalar(func(tx *Transaction) interface{} { return tx.EffectiveGasPrice }),
+		"maxFeePerGas":         txScalar(func(tx *Transaction) interface{} { return tx.MaxFeePerGas }),
+		"maxPriorityFeePerGas": txScalar(func(tx *Transaction) interface{} { return tx.MaxPriorityFeePerGas }),
+		"type":                 txScalar(func(tx *Transaction) interface{} { return tx.Type }),
+		"input":                txScalar(func(tx *Transaction) interface{} { return tx.Input }),
+		"status":               txScalar(func(tx *Transaction) interface{} { return tx.Status }),
+		"block": {typ: blockType, resolve: func(ctx context.Context, b *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			tx := parent.(*Transaction)
+			if tx.BlockNumber == nil {
+				return nil, nil
+			}
+			return nilIfNotFound(b.block(ctx, *tx.BlockNumber))
+		}},
+		"from": {typ: accountType, resolve: func(_ context.Context, _ *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return &gqlAccount{address: common.HexToAddress(parent.(*Transaction).From)}, nil
+		}},
+		"to": {typ: accountType, resolve: func(_ context.Context, _ *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			tx := parent.(*Transaction)
+			if tx.To == nil {
+				return nil, nil
+			}
+			return &gqlAccount{address: common.HexToAddress(*tx.To)}, nil
+		}},
+		"logs": {typ: logType, list: true, resolve: func(ctx context.Context, b *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			logs, err := b.transactionLogs(ctx, parent.(*Transaction).Hash)
+			if err != nil {
+				return nil, err
+			}
+			items := make([]interface{}, len(logs))
+			for i, log := range logs {
+				items[i] = log
+			}
+			return items, nil
+		}},
+	}
+
+	logType.fields = map[string]*gqlFieldDef{
+		"index":  logScalar(func(log *Log) interface{} { return log.Index }),
+		"topics": logScalar(func(log *Log) interface{} { return log.Topics }),
+		"data":   logScalar(func(log *Log) interface{} { return log.Data }),
+		"account": {typ: accountType, resolve: func(_ context.Context, _ *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return &gqlAccount{address: common.HexToAddress(parent.(*Log).Address)}, nil
+		}},
+		"transaction": {typ: transactionType, resolve: func(ctx context.Context, b *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return nilIfNotFound(b.transaction(ctx, parent.(*Log).TransactionHash))
+		}},
+	}
+
+	accountType.fields = map[string]*gqlFieldDef{
+		"address": {resolve: func(_ context.Context, _ *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+			return parent.(*gqlAccount).address.Hex(), nil
+		}},
+		"balance": accountState(func(ctx context.Context, b *backend, address common.Address, block string) (interface{}, error) {
+			balance, err := b.balance(ctx, address, block)
+			if err != nil {
+				return nil, err
+			}
+			return balance.String(), nil
+		}),
+		"transactionCount": accountState(func(ctx context.Context, b *backend, address common.Address, block string) (interface{}, error) {
+			var nonce hexutil.Uint64
+			err := b.call(ctx, &nonce, "eth_getTransactionCount", address, block)
+			return uint64(nonce), err
+		}),
+		"code": accountState(func(ctx context.Context, b *backend, address common.Address, block string) (interface{}, error) {
+			var code hexutil.Bytes
+			err := b.call(ctx, &code, "eth_getCode", address, block)
+			return code.String(), err
+		}),
+	}
+}
+
+// resolveBlock returns the block with either the number or the hash argument.
+func resolveBlock(ctx context.Context, b *backend, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	number, hasNumber, err := uintArg(args, "number")
+	if err != nil {
+		return nil, err
+	}
+	hash, err := stringArg(args, "hash", false)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case hasNumber == (hash != ""):
+		return nil, errors.New("either number or hash is required")
+	case hasNumber:
+		return nilIfNotFound(b.block(ctx, number))
+	case !txHashRegex.MatchString(hash):
+		return nil, errors.New("invalid block hash")
+	}
+	bs, err := b.blockByHash(ctx, hash)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	block := newBlock(bs)
+	block.Finalized = b.finalized(ctx, bs)
+	return block, nil
+}
+
+func blockScalar(value func(*Block) interface{}) *gqlFieldDef {
+	return &gqlFieldDef{resolve: func(_ context.Context, _ *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+		return value(parent.(*Block)), nil
+	}}
+}
+
+func txScalar(value func(*Transaction) interface{}) *gqlFieldDef {
+	return &gqlFieldDef{resolve: func(_ context.Context, _ *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+		return value(parent.(*Transaction)), nil
+	}}
+}
+
+func logScalar(value func(*Log) interface{}) *gqlFieldDef {
+	return &gqlFieldDef{resolve: func(_ context.Context, _ *backend, parent interface{}, _ map[string]interface{}) (interface{}, error) {
+		return value(parent.(*Log)), nil
+	}}
+}
+
+// accountState is a field of the account state as of the block argument,
+// the latest block when it is omitted.
+func accountState(value func(ctx context.Context, b *backend, address common.Address, block string) (interface{}, error)) *gqlFieldDef {
+	return &gqlFieldDef{args: []string{"block"}, resolve: func(ctx context.Context, b *backend, parent interface{}, args map[string]interface{}) (interface{}, error) {
+		block := "latest"
+		number, ok, err := uintArg(args, "block")
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			block = hexutil.EncodeUint64(number)
+		}
+		return value(ctx, b, parent.(*gqlAccount).address, block)
+	}}
+}
+
+// nilIfNotFound turns the values not found into null, without a typed nil
+// ending up in the result.
+func nilIfNotFound[T any](value *T, err error) (interface{}, error) {
+	if errors.Is(err, errNotFound) || value == nil && err == nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// uintArg returns the Long argument, given as a number or a decimal string.
+func uintArg(args map[string]interface{}, name string) (uint64, bool, error) {
+	var s string
+	switch value := args[name].(type) {
+	case nil:
+		return 0, false, nil
+	case json.Number:
+		s = value.String()
+	case string:
+		s = value
+	default:
+		return 0, false, fmt.Errorf("argument %q must be a number", name)
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("argument %q must be a non-negative integer", name)
+	}
+	return n, true, nil
+}
+
+func stringArg(args map[string]interface{}, name string, required bool) (string, error) {
+	switch value := args[name].(type) {
+	case nil:
+		if required {
+			return "", fmt.Errorf("argument %q is required", name)
+		}
+		return "", nil
+	case string:
+		return value, nil
+	}
+	return "", fmt.Errorf("argument %q must be a string", name)
+}
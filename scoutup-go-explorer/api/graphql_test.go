@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func noChains(uint64) (*backend, bool) {
+	return nil, false
+}
+
+func TestParseGraphQLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"unclosed selection set", `{ block(chainId: 1, number: 5) { hash }`, `expected a name, got end of query`},
+		{"empty selection set", `{ }`, `empty selection set`},
+		{"mutation", `mutation { block { hash } }`, `mutation operations are not supported`},
+		{"subscription", `subscription { block { hash } }`, `subscription operations are not supported`},
+		{"directive", `{ block @include(if: true) { hash } }`, `directives are not supported`},
+		{"directive after arguments", `{ block(number: 1) @skip(if: true) { hash } }`, `directives are not supported`},
+		{"unterminated string", `{ block(hash: "0x12) { hash } }`, `unterminated string`},
+		{"invalid escape", `{ block(hash: "\q") { hash } }`, `invalid escape \q`},
+		{"invalid unicode escape", `{ block(hash: "\u12") { hash } }`, `invalid unicode escape`},
+		{"invalid number", `{ block(number: 1.2.3) { hash } }`, `invalid number "1.2.3"`},
+		{"unexpected character", `{ block ~ }`, `unexpected character '~'`},
+		{"missing argument value", `{ block(number: ) { hash } }`, `unexpected ")"`},
+		{"missing argument colon", `{ block(number 5) { hash } }`, `expected ":", got "5"`},
+		{"variable in a default value", `query Q($a: Long = $b) { __typename }`, `unexpected variable in a default value`},
+		{"variable without a type", `query Q($a) { __typename }`, `expected ":", got ")"`},
+		{"fragment without a type condition", `fragment F { hash } { __typename }`, `expected on after fragment F`},
+		{"fragment defined twice", `fragment F on Block { hash } fragment F on Block { number } { __typename }`, `fragment "F" is defined more than once`},
+		{"fragments only", `fragment F on Block { hash }`, `the document has no operation`},
+		{"empty document", `# nothing but a comment`, `the document has no operation`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseGraphQL(test.query)
+			if err == nil {
+				t.Fatalf("parsed %q, expected an error containing %q", test.query, test.err)
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("got error %q, expected it to contain %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestParseGraphQL(t *testing.T) {
+	doc, err := parseGraphQL(`
+		# comments, commas and whitespace are ignored
+		query Blocks($number: Long! = 5, $hashes: [String]) {
+			latest: block(chainId: 1, number: $number) { ...fields, parent { ... on Block { hash } } }
+			other: block(chainId: 1, hash: "0xA\"") { ... { number } }
+		}
+		fragment fields on Block { number hash }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.operations) != 1 || doc.operations[0].name != "Blocks" {
+		t.Fatalf("unexpected operations %+v", doc.operations)
+	}
+	op := doc.operations[0]
+	if len(op.variables) != 2 || !op.variables[0].nonNull || op.variables[0].defValue != json.Number("5") || op.variables[1].nonNull {
+		t.Fatalf("unexpected variables %+v", op.variables)
+	}
+
+	latest := op.selections[0]
+	if latest.alias != "latest" || latest.name != "block" || latest.args["number"] != gqlVariable("number") || latest.args["chainId"] != json.Number("1") {
+		t.Fatalf("unexpected selection %+v", latest)
+	}
+	if latest.selections[0].fragment != "fields" {
+		t.Fatalf("expected a spread of fields, got %+v", latest.selections[0])
+	}
+	if inline := latest.selections[1].selections[0]; !inline.inline || inline.typeCondition != "Block" {
+		t.Fatalf("expected an inline fragment on Block, got %+v", inline)
+	}
+	other := op.selections[1]
+	if other.args["hash"] != `0xA"` {
+		t.Fatalf("unexpected string argument %q", other.args["hash"])
+	}
+	if inline := other.selections[0]; !inline.inline || inline.typeCondition != "" {
+		t.Fatalf("expected an inline fragment without a type condition, got %+v", inline)
+	}
+	if fragment := doc.fragments["fields"]; fragment == nil || fragment.typeCondition != "Block" || len(fragment.selections) != 2 {
+		t.Fatalf("unexpected fragment %+v", fragment)
+	}
+}
+
+func TestGraphQLValidation(t *testing.T) {
+	// parent { parent { ... } } nested as deep as the limit allows
+	deep := "hash"
+	for range maxGraphQLDepth - 1 {
+		deep = "parent { " + deep + " }"
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		variables map[string]interface{}
+		err       string
+	}{
+		{name: "unknown root field", query: `{ blocks { hash } }`, err: `cannot query field "blocks" on type Query`},
+		{name: "unknown field", query: `{ block(chainId: 1, number: 1) { foo } }`, err: `cannot query field "foo" on type Block`},
+		{name: "unknown argument", query: `{ block(chainId: 1, height: 1) { hash } }`, err: `unknown argument "height" of field Query.block`},
+		{name: "argument of a scalar", query: `{ block(chainId: 1, number: 1) { hash(full: true) } }`, err: `unknown argument "full" of field Block.hash`},
+		{name: "subfields of a scalar", query: `{ block(chainId: 1, number: 1) { hash { length } } }`, err: `field Block.hash has no subfields`},
+		{name: "object without subfields", query: `{ block(chainId: 1, number: 1) }`, err: `field Query.block of type Block needs a selection of subfields`},
+		{name: "conflicting aliases", query: `{ block(chainId: 1, number: 1) { a: hash a: number } }`, err: `fields "hash" and "number" conflict as "a"`},
+
+		{name: "unknown fragment", query: `{ block(chainId: 1, number: 1) { ...missing } }`, err: `unknown fragment "missing"`},
+		{name: "fragment spreading itself", query: `{ block(chainId: 1, number: 1) { ...F } } fragment F on Block { parent { ...F } }`, err: `fragment "F" spreads itself`},
+		{name: "fragments spreading each other", query: `{ block(chainId: 1, number: 1) { ...A } } fragment A on Block { parent { ...B } } fragment B on Block { parent { ...A } }`, err: `spreads itself`},
+		{name: "fragment with an unknown field", query: `{ block(chainId: 1, number: 1) { ...F } } fragment F on Block { foo }`, err: `cannot query field "foo" on type Block`},
+
+		{name: "undefined variable", query: `{ block(chainId: 1, number: $n) { hash } }`, err: `variable $n is not defined`},
+		{name: "undefined variable in a list", query: `query Q($n: Long) { block(chainId: 1, number: [$n, $m]) { hash } }`, err: `variable $m is not defined`},
+		{name: "missing required variable", query: `query Q($n: Long!) { block(chainId: 1, number: $n) { hash } }`, err: `variable $n is required`},
+		{name: "null required variable", query: `query Q($n: Long!) { block(chainId: 1, number: $n) { hash } }`, variables: map[string]interface{}{"n": nil}, err: `variable $n is required`},
+		{name: "several operations", query: `query A { __typename } query B { __typename }`, err: `operationName is required`},
+		{name: "unknown operation", query: `query A { __typename }`, operation: "B", err: `unknown operation "B"`},
+		{name: "missing query", query: "  ", err: `missing query`},
+
+		{name: "too deep", query: `{ block(chainId: 1, number: 1) { parent { ` + deep + ` } } }`, err: `query depth exceeds the limit of 8`},
+		{name: "too deep through a fragment", query: `{ block(chainId: 1, number: 1) { ...F } } fragment F on Block { parent { ` + deep + ` } }`, err: `query depth exceeds the limit of 8`},
+		{name: "too complex", query: `{ block(chainId: 1, number: 1) { transactions { logs { transaction { logs { index } } } } } }`, err: `exceeds the limit of 5000`},
+		{name: "too complex with aliases", query: `{ a: block(chainId: 1, number: 1) { ...T } b: block(chainId: 1, number: 2) { ...T } c: block(chainId: 1, number: 3) { ...T } }
+			fragment T on Block { transactions { hash from { address balance code transactionCount } to { address balance code transactionCount } logs { index data topics } } }`, err: `exceeds the limit of 5000`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &graphQLRequest{Query: test.query, OperationName: test.operation, Variables: test.variables}
+			_, err := newGraphQLExecution(context.Background(), noChains, req)
+			if err == nil {
+				t.Fatalf("validated %q, expected an error containing %q", test.query, test.err)
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("got error %q, expected it to contain %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestGraphQLComplexity(t *testing.T) {
+	deep := "hash"
+	for range maxGraphQLDepth - 2 {
+		deep = "parent { " + deep + " }"
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		operation  string
+		complexity int
+	}{
+		{name: "scalars", query: `{ block(chainId: 1, number: 1) { hash number } }`, complexity: 3},
+		{name: "typename is free", query: `{ __typename block(chainId: 1, number: 1) { __typename hash } }`, complexity: 2},
+		{name: "list items", query: `{ block(chainId: 1, number: 5) { hash transactions { hash from { address balance } } } }`, complexity: 1 + 1 + 1 + graphQLListCost*4},
+		{name: "fragment", query: `{ block(chainId: 1, number: 1) { ...F } } fragment F on Block { hash number }`, complexity: 3},
+		{name: "fragment merged with the fields", query: `{ block(chainId: 1, number: 1) { number ...F } } fragment F on Block { hash number }`, complexity: 3},
+		{name: "fragment of another type", query: `{ block(chainId: 1, number: 1) { hash ...F } } fragment F on Transaction { hash }`, complexity: 2},
+		{name: "inline fragments", query: `{ block(chainId: 1, number: 1) { ... on Block { hash } ... { number } ... on Log { index } } }`, complexity: 3},
+		{name: "merged subselections", query: `{ block(chainId: 1, number: 1) { parent { hash } parent { number } } }`, complexity: 4},
+		{name: "aliases", query: `{ a: block(chainId: 1, number: 1) { hash } b: block(chainId: 2, number: 1) { hash } }`, complexity: 4},
+		{name: "fragment reused in siblings", query: `{ block(chainId: 1, number: 1) { ...F parent { ...F } } } fragment F on Block { hash }`, complexity: 4},
+		{name: "as deep as allowed", query: `{ block(chainId: 1, number: 1) { ` + deep + ` } }`, complexity: maxGraphQLDepth},
+		{name: "selected operation", query: `query A { block(chainId: 1, number: 1) { hash } } query B { __typename }`, operation: "A", complexity: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &graphQLRequest{Query: test.query, OperationName: test.operation}
+			exec, err := newGraphQLExecution(context.Background(), noChains, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			complexity, err := exec.validate(exec.operation.selections, queryType, 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if complexity != test.complexity {
+				t.Fatalf("got complexity %d, expected %d", complexity, test.complexity)
+			}
+		})
+	}
+}
+
+func TestGraphQLExecution(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		response  string
+	}{
+		{
+			name:     "typename",
+			query:    `{ __typename alias: __typename }`,
+			response: `{"data":{"__typename":"Query","alias":"Query"}}`,
+		},
+		{
+			name:     "missing chain id",
+			query:    `{ block(number: 1) { hash } }`,
+			response: `{"data":{"block":null},"errors":[{"message":"argument \"chainId\" is required","path":["block"]}]}`,
+		},
+		{
+			name:     "unknown chain",
+			query:    `{ first: block(chainId: 7, number: 1) { hash } __typename }`,
+			response: `{"data":{"first":null,"__typename":"Query"},"errors":[{"message":"unknown chain 7","path":["first"]}]}`,
+		},
+		{
+			name:      "chain id from a variable",
+			query:     `query Q($chain: Long!) { account(chainId: $chain, address: "0x0000000000000000000000000000000000000001") { address } }`,
+			variables: map[string]interface{}{"chain": json.Number("9")},
+			response:  `{"data":{"account":null},"errors":[{"message":"unknown chain 9","path":["account"]}]}`,
+		},
+		{
+			name:     "chain id from a default value",
+			query:    `query Q($chain: Long = "11") { transaction(chainId: $chain, hash: "0x01") { hash } }`,
+			response: `{"data":{"transaction":null},"errors":[{"message":"unknown chain 11","path":["transaction"]}]}`,
+		},
+		{
+			name:     "invalid chain id",
+			query:    `{ block(chainId: -1, number: 1) { hash } }`,
+			response: `{"data":{"block":null},"errors":[{"message":"argument \"chainId\" must be a non-negative integer","path":["block"]}]}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &graphQLRequest{Query: test.query, Variables: test.variables}
+			exec, err := newGraphQLExecution(context.Background(), noChains, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := json.Marshal(exec.run())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(response) != test.response {
+				t.Fatalf("got response %s, expected %s", response, test.response)
+			}
+		})
+	}
+}
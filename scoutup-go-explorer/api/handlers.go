@@ -0,0 +1,402 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blockscout/scoutup/metrics"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// the number of blocks fetched from Blockscout at once
+	blockPageConcurrency = 8
+	maxABISize           = 1 << 20
+)
+
+var txHashRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /chains", s.handleChains)
+	mux.HandleFunc("GET /chains/{chainID}/blocks/{number}", s.handleBlock)
+	mux.HandleFunc("GET /chains/{chainID}/blocks", s.handleBlocks)
+	mux.HandleFunc("GET /chains/{chainID}/tx/{hash}", s.handleTransaction)
+	mux.HandleFunc("GET /chains/{chainID}/tx/{hash}/internal", s.handleInternalTransactions)
+	mux.HandleFunc("GET /chains/{chainID}/address/{address}", s.handleAddress)
+	mux.HandleFunc("GET /chains/{chainID}/token/{address}/holders", s.handleTokenHolders)
+	mux.HandleFunc("GET /chains/{chainID}/nft/{address}/{tokenID}", s.handleNFT)
+	mux.HandleFunc("GET /chains/{chainID}/search", s.handleSearch)
+	mux.HandleFunc("GET /chains/{chainID}/pending", s.handlePending)
+	mux.HandleFunc("GET /chains/{chainID}/stats", s.handleStats)
+	mux.HandleFunc("GET /chains/{chainID}/export", s.handleExport)
+	mux.HandleFunc("GET /chains/{chainID}/logs", s.handleLogs)
+	mux.HandleFunc("GET /graphql", s.handleGraphQL)
+	mux.HandleFunc("POST /graphql", s.handleGraphQL)
+	mux.HandleFunc("POST /abi", s.handleRegisterABI)
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	return mux
+}
+
+func (s *Server) handleBlock(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	number, err := strconv.ParseUint(r.PathValue("number"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid block number")
+		return
+	}
+
+	block, err := chain.blockDetail(r.Context(), number)
+	if err != nil {
+		s.writeBackendError(w, err, fmt.Sprintf("block %d is not indexed", number))
+		return
+	}
+	writeJSON(w, http.StatusOK, block)
+}
+
+func (s *Server) handleBlocks(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	from, err := strconv.ParseUint(query.Get("from"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid or missing from")
+		return
+	}
+	to, err := strconv.ParseUint(query.Get("to"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid or missing to")
+		return
+	}
+	if to < from {
+		writeError(w, http.StatusBadRequest, "to must not be lower than from")
+		return
+	}
+	limit, err := pageLimit(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start := from
+	if cursor := query.Get("cursor"); cursor != "" {
+		start, err = decodeCursor(cursor)
+		if err != nil || start < from || start > to {
+			writeError(w, http.StatusBadRequest, errInvalidCursor.Error())
+			return
+		}
+	}
+	end := to
+	if end-start >= limit {
+		end = start + limit - 1
+	}
+
+	blocks, err := s.fetchBlocks(r.Context(), chain, start, end)
+	if err != nil {
+		s.writeBackendError(w, err, "")
+		return
+	}
+	list := &BlockList{Blocks: blocks}
+	if end < to {
+		list.NextCursor = encodeCursor(end + 1)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// fetchBlocks fetches the blocks from..to concurrently, the ones not indexed
+// yet are left out.
+func (s *Server) fetchBlocks(ctx context.Context, chain *backend, from, to uint64) ([]*Block, error) {
+	blocks := make([]*Block, to-from+1)
+	errs := make([]error, len(blocks))
+	sem := make(chan struct{}, blockPageConcurrency)
+	var wg sync.WaitGroup
+	for i := range blocks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			blocks[i], errs[i] = chain.block(ctx, from+uint64(i))
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, errNotFound) {
+			return nil, err
+		}
+	}
+	return slices.DeleteFunc(blocks, func(b *Block) bool { return b == nil }), nil
+}
+
+func (s *Server) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	hash := r.PathValue("hash")
+	if !txHashRegex.MatchString(hash) {
+		writeError(w, http.StatusBadRequest, "invalid transaction hash")
+		return
+	}
+
+	tx, err := chain.transactionDetail(r.Context(), hash)
+	if err != nil {
+		s.writeBackendError(w, err, fmt.Sprintf("transaction %s is not indexed", hash))
+		return
+	}
+	writeJSON(w, http.StatusOK, tx)
+}
+
+func (s *Server) handleInternalTransactions(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	hash := r.PathValue("hash")
+	if !txHashRegex.MatchString(hash) {
+		writeError(w, http.StatusBadRequest, "invalid transaction hash")
+		return
+	}
+	page, ok := pageCursor(w, r)
+	if !ok {
+		return
+	}
+
+	calls, next, err := chain.internalTransactions(r.Context(), hash, page)
+	if err != nil {
+		s.writeBackendError(w, err, fmt.Sprintf("transaction %s is not indexed", hash))
+		return
+	}
+	resp := &InternalTransactions{Hash: hash, Calls: calls}
+	if next != nil {
+		resp.NextCursor = encodePageCursor(next)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleAddress(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	address, ok := pathAddress(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	block := query.Get("block")
+	if block != "" {
+		number, err := strconv.ParseUint(block, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid block number")
+			return
+		}
+		block = hexutil.EncodeUint64(number)
+	}
+	page, ok := pageCursor(w, r)
+	if !ok {
+		return
+	}
+
+	balance, err := chain.balance(r.Context(), address, block)
+	if err != nil {
+		s.writeBackendError(w, fmt.Errorf("cannot get the balance: %w", err), "")
+		return
+	}
+	txs, next, err := chain.addressTransactions(r.Context(), address, page)
+	if err != nil {
+		s.writeBackendError(w, err, "")
+		return
+	}
+
+	resp := &Address{
+		Address:      address.Hex(),
+		Balance:      balance.String(),
+		Block:        "latest",
+		Transactions: txs,
+	}
+	if block != "" {
+		resp.Block = query.Get("block")
+	}
+	if next != nil {
+		resp.NextCursor = encodePageCursor(next)
+	}
+	if balance, ok := chain.chain.GenesisAlloc[address]; ok {
+		resp.Genesis = &GenesisBalance{BlockNumber: 0, Balance: balance.String()}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleTokenHolders(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	address, ok := pathAddress(w, r)
+	if !ok {
+		return
+	}
+	if !chain.chain.WatchesToken(address) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("token %s is not watched", address.Hex()))
+		return
+	}
+	page, ok := pageCursor(w, r)
+	if !ok {
+		return
+	}
+
+	token, holders, next, err := chain.tokenHolders(r.Context(), address, page)
+	if err != nil {
+		s.writeBackendError(w, err, fmt.Sprintf("token %s is not indexed", address.Hex()))
+		return
+	}
+	resp := &TokenHolders{Token: token, Holders: holders}
+	if next != nil {
+		resp.NextCursor = encodePageCursor(next)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlePending(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	if chain.mempool == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("mempool of chain %d is not watched", chain.chain.ChainID))
+		return
+	}
+
+	pending := chain.mempool.Pending()
+	resp := &PendingTransactions{Transactions: make([]*PendingTransaction, len(pending))}
+	for i, tx := range pending {
+		resp.Transactions[i] = newPendingTransaction(tx)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleRegisterABI sets the ABI of a contract, for the admins only as it
+// changes how the transactions are decoded.
+func (s *Server) handleRegisterABI(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	var req ABIRegistration
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxABISize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err))
+		return
+	}
+	chain, ok := s.lookup(req.ChainID)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("chain %d is not configured", req.ChainID))
+		return
+	}
+	if !strings.HasPrefix(req.Address, "0x") || !common.IsHexAddress(req.Address) {
+		writeError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+	contract, err := abi.JSON(bytes.NewReader(req.ABI))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid abi: %v", err))
+		return
+	}
+
+	address := common.HexToAddress(req.Address)
+	switch err := chain.abis.register(address, &contract); {
+	case errors.Is(err, errConfiguredABI):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInsufficientStorage, err.Error())
+		return
+	}
+	chain.log.Info("Registered contract ABI", "address", address)
+
+	methods := make([]string, 0, len(contract.Methods))
+	for _, method := range contract.Methods {
+		methods = append(methods, method.Sig)
+	}
+	slices.Sort(methods)
+	writeJSON(w, http.StatusCreated, &RegisteredABI{ChainID: req.ChainID, Address: address.Hex(), Methods: methods})
+}
+
+// pathAddress parses the address path value, writing the error response when it is malformed.
+func pathAddress(w http.ResponseWriter, r *http.Request) (common.Address, bool) {
+	hex := r.PathValue("address")
+	if !strings.HasPrefix(hex, "0x") || !common.IsHexAddress(hex) {
+		writeError(w, http.StatusBadRequest, "invalid address")
+		return common.Address{}, false
+	}
+	return common.HexToAddress(hex), true
+}
+
+// pageCursor decodes the cursor query param of the Blockscout lists into the
+// params of the requested page, nil for the first one.
+func pageCursor(w http.ResponseWriter, r *http.Request) (url.Values, bool) {
+	cursor := r.URL.Query().Get("cursor")
+	if cursor == "" {
+		return nil, true
+	}
+	page, err := decodePageCursor(cursor)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return page, true
+}
+
+// chain resolves the chainID path value to one of the configured chains,
+// writing the error response when there is none.
+func (s *Server) chain(w http.ResponseWriter, r *http.Request) (*backend, bool) {
+	chainID, err := strconv.ParseUint(r.PathValue("chainID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid chain id")
+		return nil, false
+	}
+	chain, ok := s.lookup(chainID)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("chain %d is not configured", chainID))
+		return nil, false
+	}
+	return chain, true
+}
+
+func (s *Server) writeBackendError(w http.ResponseWriter, err error, notFound string) {
+	if errors.Is(err, errNotFound) {
+		writeError(w, http.StatusNotFound, notFound)
+		return
+	}
+	s.log.Warn("API request failed", "err", err)
+	writeError(w, http.StatusBadGateway, err.Error())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, &Error{Error: msg})
+}
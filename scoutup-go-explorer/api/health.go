@@ -0,0 +1,106 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const readinessTimeout = 5 * time.Second
+
+type ChainReadiness struct {
+	ChainID uint64 `json:"chainId"`
+	Name    string `json:"name"`
+	Ready   bool   `json:"ready"`
+	// why the chain is not ready
+	Reason string `json:"reason,omitempty"`
+}
+
+type Readiness struct {
+	Ready  bool              `json:"ready"`
+	Chains []*ChainReadiness `json:"chains"`
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz reports ready once every chain's indexer is running, its node
+// is reachable and Blockscout has indexed at least one of its blocks.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	s.mu.RLock()
+	indexers := s.indexers
+	s.mu.RUnlock()
+
+	chains := s.backends()
+	pending := s.pendingChains()
+	readiness := &Readiness{Ready: len(pending) == 0, Chains: make([]*ChainReadiness, 0, len(chains)+len(pending))}
+	for name, reason := range pending {
+		readiness.Chains = append(readiness.Chains, &ChainReadiness{Name: name, Reason: fmt.Sprintf("not started: %s", reason)})
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for chainID, chain := range chains {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := &ChainReadiness{ChainID: chainID, Name: chain.name, Ready: true}
+			if err := s.checkReady(ctx, chainID, chain, indexers); err != nil {
+				status.Ready = false
+				status.Reason = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			readiness.Chains = append(readiness.Chains, status)
+			readiness.Ready = readiness.Ready && status.Ready
+		}()
+	}
+	wg.Wait()
+	slices.SortFunc(readiness.Chains, func(a, b *ChainReadiness) int {
+		return cmp.Or(cmp.Compare(a.ChainID, b.ChainID), cmp.Compare(a.Name, b.Name))
+	})
+
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, readiness)
+}
+
+func (s *Server) checkReady(ctx context.Context, chainID uint64, chain *backend, indexers Indexers) error {
+	if indexers != nil {
+		// e.g. a crashed instance or a node serving blocks failing the verification
+		if status, err := indexers.IndexerStatus(chainID); status != "running" {
+			if err != nil {
+				return fmt.Errorf("indexer is %s: %w", status, err)
+			}
+			return fmt.Errorf("indexer is %s", status)
+		}
+	}
+	client, err := chain.node(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get the node head: %w", err)
+	}
+	var head hexutil.Uint64
+	if err := client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+		return fmt.Errorf("cannot get the node head: %w", err)
+	}
+
+	_, err = chain.indexedHeight(ctx)
+	if errors.Is(err, errNotIndexed) && uint64(head) < chain.chain.FirstBlock {
+		// nothing to index until the chain reaches the first block
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// the most logs Blockscout returns at once for a getLogs query
+const maxBlockscoutLogs = 1000
+
+var topicRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+// logFilter is the address and the topics the logs of from..to must all match,
+// the empty ones match anything.
+type logFilter struct {
+	from, to uint64
+	address  *common.Address
+	topics   [4]string
+}
+
+type bsLogs struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  []*bsLogsResult `json:"result"`
+}
+
+// bsLogsResult is a log as returned by Blockscout's Etherscan-compatible API, with hex numbers.
+type bsLogsResult struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	BlockNumber     string   `json:"blockNumber"`
+	LogIndex        string   `json:"logIndex"`
+	TransactionHash string   `json:"transactionHash"`
+}
+
+// handleLogs serves the indexed logs matching the filter within a block range,
+// the way eth_getLogs does, ordered by block and log index.
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := &logFilter{}
+	var err error
+	if filter.from, err = strconv.ParseUint(query.Get("from"), 10, 64); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid or missing from")
+		return
+	}
+	if filter.to, err = strconv.ParseUint(query.Get("to"), 10, 64); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid or missing to")
+		return
+	}
+	if filter.to < filter.from {
+		writeError(w, http.StatusBadRequest, "to must not be lower than from")
+		return
+	}
+	if span := chain.chain.MaxLogsBlockRangeOrDefault(); filter.to-filter.from >= span {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("the block range exceeds the limit of %d blocks", span))
+		return
+	}
+	if address := query.Get("address"); address != "" {
+		if !common.IsHexAddress(address) {
+			writeError(w, http.StatusBadRequest, "invalid address")
+			return
+		}
+		a := common.HexToAddress(address)
+		filter.address = &a
+	}
+	for i := range filter.topics {
+		topic := query.Get(fmt.Sprintf("topic%d", i))
+		if topic != "" && !topicRegex.MatchString(topic) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid topic%d", i))
+			return
+		}
+		filter.topics[i] = topic
+	}
+	if filter.address == nil && filter.topics == [4]string{} {
+		writeError(w, http.StatusBadRequest, "address or a topic is required")
+		return
+	}
+	limit, err := pageLimit(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var after *logPosition
+	if cursor := query.Get("cursor"); cursor != "" {
+		position, err := decodeLogCursor(cursor)
+		if err != nil || position.block < filter.from || position.block > filter.to {
+			writeError(w, http.StatusBadRequest, errInvalidCursor.Error())
+			return
+		}
+		after = position
+	}
+
+	logs, more, err := chain.logs(r.Context(), filter, after, int(limit))
+	if err != nil {
+		s.writeBackendError(w, err, "")
+		return
+	}
+	list := &LogList{Logs: logs}
+	if more {
+		last := logs[len(logs)-1]
+		list.NextCursor = encodeLogCursor(&logPosition{block: last.BlockNumber, index: last.Index})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// logPosition is where a page of logs ends, the next one starting right after it.
+type logPosition struct {
+	block, index uint64
+}
+
+func (p *logPosition) before(log *Log) bool {
+	return p.block < log.BlockNumber || p.block == log.BlockNumber && p.index < log.Index
+}
+
+func encodeLogCursor(p *logPosition) string {
+	return encodePageCursor(url.Values{
+		"block": {strconv.FormatUint(p.block, 10)},
+		"index": {strconv.FormatUint(p.index, 10)},
+	})
+}
+
+func decodeLogCursor(cursor string) (*logPosition, error) {
+	params, err := decodePageCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	block, err := strconv.ParseUint(params.Get("block"), 10, 64)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	index, err := strconv.ParseUint(params.Get("index"), 10, 64)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return &logPosition{block: block, index: index}, nil
+}
+
+// logs returns up to limit logs matching the filter after the position, if
+// any, and whether there are more. Blockscout returns a bounded number of logs
+// per query, from the block of the position on.
+func (b *backend) logs(ctx context.Context, filter *logFilter, after *logPosition, limit int) ([]*Log, bool, error) {
+	from := filter.from
+	if after != nil {
+		from = after.block
+	}
+	query := url.Values{
+		"module":    {"logs"},
+		"action":    {"getLogs"},
+		"fromBlock": {strconv.FormatUint(from, 10)},
+		"toBlock":   {strconv.FormatUint(filter.to, 10)},
+	}
+	if filter.address != nil {
+		query.Set("address", filter.address.Hex())
+	}
+	for i, topic := range filter.topics {
+		if topic == "" {
+			continue
+		}
+		query.Set(fmt.Sprintf("topic%d", i), topic)
+		// every topic has to match, as with eth_getLogs
+		for j := range i {
+			if filter.topics[j] != "" {
+				query.Set(fmt.Sprintf("topic%d_%d_opr", j, i), "and")
+			}
+		}
+	}
+
+	var resp bsLogs
+	if err := b.getRPCAPI(ctx, query, &resp); err != nil {
+		return nil, false, err
+	}
+	if resp.Status != "1" && len(resp.Result) == 0 && resp.Message != "No logs found" {
+		return nil, false, fmt.Errorf("blockscout of %s cannot get the logs: %s", b.name, resp.Message)
+	}
+
+	logs := make([]*Log, 0, len(resp.Result))
+	for _, result := range resp.Result {
+		log, err := newLogsResult(result)
+		if err != nil {
+			return nil, false, fmt.Errorf("unexpected log from blockscout: %w", err)
+		}
+		if after == nil || after.before(log) {
+			logs = append(logs, log)
+		}
+	}
+	slices.SortFunc(logs, func(a, b *Log) int {
+		if a.BlockNumber != b.BlockNumber {
+			return cmp.Compare(a.BlockNumber, b.BlockNumber)
+		}
+		return cmp.Compare(a.Index, b.Index)
+	})
+
+	truncated := len(resp.Result) >= maxBlockscoutLogs
+	if truncated && len(logs) == 0 {
+		return nil, false, fmt.Errorf("block %d has more than %d matching logs, narrow the filter", from, maxBlockscoutLogs)
+	}
+	if len(logs) > limit {
+		return logs[:limit], true, nil
+	}
+	return logs, truncated, nil
+}
+
+func newLogsResult(l *bsLogsResult) (*Log, error) {
+	blockNumber, err := quantity(l.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("blockNumber: %w", err)
+	}
+	index, err := quantity(l.LogIndex)
+	if err != nil {
+		return nil, fmt.Errorf("logIndex: %w", err)
+	}
+	topics := make([]string, 0, len(l.Topics))
+	for _, topic := range l.Topics {
+		if topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return &Log{
+		Index:           index,
+		Address:         checksum(l.Address),
+		Topics:          topics,
+		Data:            l.Data,
+		BlockNumber:     blockNumber,
+		TransactionHash: l.TransactionHash,
+	}, nil
+}
+
+// quantity decodes a hex number of the Etherscan-compatible API, which has
+// "0x" for zero.
+func quantity(s string) (uint64, error) {
+	if s == "0x" {
+		return 0, nil
+	}
+	n, err := hexutil.DecodeUint64(s)
+	if errors.Is(err, hexutil.ErrLeadingZero) {
+		return strconv.ParseUint(s[2:], 16, 64)
+	}
+	return n, err
+}
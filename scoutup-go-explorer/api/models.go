@@ -0,0 +1,466 @@
+package api
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/blockscout/scoutup/mempool"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// The API models are decoupled from the Blockscout ones, so that the API
+// stays the same across Blockscout versions. Amounts are decimal strings.
+
+type Block struct {
+	Number           uint64    `json:"number"`
+	Hash             string    `json:"hash"`
+	ParentHash       string    `json:"parentHash"`
+	Timestamp        time.Time `json:"timestamp"`
+	Miner            string    `json:"miner"`
+	Size             uint64    `json:"size"`
+	GasUsed          string    `json:"gasUsed"`
+	GasLimit         string    `json:"gasLimit"`
+	TransactionCount uint64    `json:"transactionCount"`
+	// nil before London
+	BaseFeePerGas *string `json:"baseFeePerGas"`
+	// the block can no longer be reorged, see confirmations of the chain config
+	Finalized bool `json:"finalized"`
+}
+
+// BlockDetail is a single block along with its validator withdrawals.
+type BlockDetail struct {
+	*Block
+	// empty for blocks before Shanghai
+	Withdrawals []*Withdrawal `json:"withdrawals"`
+}
+
+type Withdrawal struct {
+	Index          uint64 `json:"index"`
+	ValidatorIndex uint64 `json:"validatorIndex"`
+	Address        string `json:"address"`
+	// in wei
+	Amount string `json:"amount"`
+}
+
+type Transaction struct {
+	Hash string `json:"hash"`
+	// nil while the transaction is pending
+	BlockNumber *uint64    `json:"blockNumber"`
+	Index       *uint64    `json:"index"`
+	Timestamp   *time.Time `json:"timestamp"`
+	From        string     `json:"from"`
+	// nil for contract creations
+	To       *string `json:"to"`
+	Value    string  `json:"value"`
+	Nonce    uint64  `json:"nonce"`
+	Gas      string  `json:"gas"`
+	GasUsed  string  `json:"gasUsed"`
+	GasPrice string  `json:"gasPrice"`
+	Input    string  `json:"input"`
+	// 0 legacy, 1 access list, 2 EIP-1559, 3 blob
+	Type *uint64 `json:"type"`
+	// nil for legacy transactions
+	MaxFeePerGas         *string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *string `json:"maxPriorityFeePerGas"`
+	// the price actually paid per gas, taken from the receipt, nil while pending
+	EffectiveGasPrice *string `json:"effectiveGasPrice"`
+	// nil for contract creations and transfers without calldata
+	DecodedInput *DecodedInput `json:"decodedInput"`
+	// success or failed as of the receipt, nil while pending
+	Status *string `json:"status"`
+	// set by the transaction endpoint for the failed transactions
+	RevertReason *RevertReason `json:"revertReason,omitempty"`
+}
+
+// Log is an event emitted by a contract.
+type Log struct {
+	Index uint64 `json:"index"`
+	// the emitting contract
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	BlockNumber     uint64   `json:"blockNumber"`
+	TransactionHash string   `json:"transactionHash"`
+}
+
+type LogList struct {
+	Logs []*Log `json:"logs"`
+	// set when more logs match the filter
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// RevertReason is what a failed transaction reverted with.
+type RevertReason struct {
+	// 0x when the transaction failed without revert data, e.g. out of gas
+	Data string `json:"data"`
+	// error for Error(string), panic for Panic(uint256), custom otherwise
+	Kind string `json:"kind,omitempty"`
+	// the error message, the panic description or the custom error when the
+	// ABI of the contract is known
+	Message *string `json:"message"`
+}
+
+// InternalTransaction is a call made by a contract within a transaction.
+type InternalTransaction struct {
+	Index uint64 `json:"index"`
+	// call, create, selfdestruct or reward
+	Type string `json:"type"`
+	// call, delegatecall, staticcall or callcode, nil for other types
+	CallType *string `json:"callType"`
+	From     string  `json:"from"`
+	// the callee or the created contract
+	To *string `json:"to"`
+	// in wei
+	Value   string `json:"value"`
+	Success bool   `json:"success"`
+	// nil for successful calls
+	Error *string `json:"error"`
+}
+
+type InternalTransactions struct {
+	Hash  string                 `json:"hash"`
+	Calls []*InternalTransaction `json:"calls"`
+	// set when the transaction has more internal transactions
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type DecodedInput struct {
+	Selector string `json:"selector"`
+	// the method signature, nil when the ABI of the contract is unknown or
+	// has no method with the selector
+	Method *string         `json:"method"`
+	Params []*DecodedParam `json:"params,omitempty"`
+}
+
+type DecodedParam struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// ABIRegistration is the body of POST /abi.
+type ABIRegistration struct {
+	ChainID uint64          `json:"chainId"`
+	Address string          `json:"address"`
+	ABI     json.RawMessage `json:"abi"`
+}
+
+type RegisteredABI struct {
+	ChainID uint64   `json:"chainId"`
+	Address string   `json:"address"`
+	Methods []string `json:"methods"`
+}
+
+type BlockList struct {
+	Blocks []*Block `json:"blocks"`
+	// set when there are more blocks in the requested range
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type Address struct {
+	Address string `json:"address"`
+	// in wei, as of the requested block
+	Balance string `json:"balance"`
+	// the block the balance is taken at, a number or a tag
+	Block        string         `json:"block"`
+	Transactions []*Transaction `json:"transactions"`
+	// set when the address has more transactions
+	NextCursor string `json:"nextCursor,omitempty"`
+	// the opening balance of the genesis alloc, which no transaction accounts for
+	Genesis *GenesisBalance `json:"genesis,omitempty"`
+}
+
+// GenesisBalance is a balance imported from the chain's genesis alloc.
+type GenesisBalance struct {
+	BlockNumber uint64 `json:"blockNumber"`
+	// in wei
+	Balance string `json:"balance"`
+}
+
+type Token struct {
+	Address string `json:"address"`
+	// the metadata is nil when the contract does not implement it
+	Name        *string `json:"name"`
+	Symbol      *string `json:"symbol"`
+	Decimals    *uint8  `json:"decimals"`
+	TotalSupply *string `json:"totalSupply"`
+	Type        string  `json:"type"`
+}
+
+type TokenHolder struct {
+	Address string `json:"address"`
+	// in the smallest unit of the token
+	Balance string `json:"balance"`
+}
+
+type TokenHolders struct {
+	Token   *Token         `json:"token"`
+	Holders []*TokenHolder `json:"holders"`
+	// set when the token has more holders
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// NFT is a token of an ERC-721 collection.
+type NFT struct {
+	Collection *Token `json:"collection"`
+	TokenID    string `json:"tokenId"`
+	// nil once the token is burned
+	Owner     *string        `json:"owner"`
+	Transfers []*NFTTransfer `json:"transfers"`
+	// set when the token has more transfers
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type NFTTransfer struct {
+	TransactionHash string `json:"transactionHash"`
+	// nil with older Blockscout versions
+	BlockNumber *uint64    `json:"blockNumber"`
+	LogIndex    uint64     `json:"logIndex"`
+	Timestamp   *time.Time `json:"timestamp"`
+	From        string     `json:"from"`
+	To          string     `json:"to"`
+	// transfer, mint or burn
+	Type string `json:"type"`
+}
+
+type PendingTransaction struct {
+	Hash string `json:"hash"`
+	From string `json:"from"`
+	// nil for contract creations
+	To    *string `json:"to"`
+	Nonce uint64  `json:"nonce"`
+	Value string  `json:"value"`
+	Gas   string  `json:"gas"`
+	// nil when the node does not report it
+	GasPrice *string `json:"gasPrice"`
+	Type     uint64  `json:"type"`
+	// nil for legacy transactions
+	MaxFeePerGas         *string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *string `json:"maxPriorityFeePerGas"`
+	Input                string  `json:"input"`
+	// pending, queued or dropped
+	Status    string    `json:"status"`
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+type PendingTransactions struct {
+	Transactions []*PendingTransaction `json:"transactions"`
+}
+
+type SearchResult struct {
+	// block, transaction, address or token
+	Type string `json:"type"`
+	// the block number, the transaction hash or the address
+	ID string `json:"id"`
+	// the API path of the resource
+	Path string `json:"path"`
+}
+
+type SearchResults struct {
+	Query string `json:"query"`
+	// empty when nothing indexed matches the query
+	Results []*SearchResult `json:"results"`
+}
+
+// Stats aggregates the blocks indexed within the window, which is capped to
+// the first indexed block.
+type Stats struct {
+	Window string `json:"window"`
+	// the oldest and newest block of the window, nil when it has no block
+	FromBlock        *uint64 `json:"fromBlock"`
+	ToBlock          *uint64 `json:"toBlock"`
+	BlockCount       uint64  `json:"blockCount"`
+	TransactionCount uint64  `json:"transactionCount"`
+	// per block
+	AvgGasUsed    string `json:"avgGasUsed"`
+	MedianGasUsed string `json:"medianGasUsed"`
+	MaxGasUsed    string `json:"maxGasUsed"`
+	// nil when no block of the window has a base fee
+	AvgBaseFeePerGas *string `json:"avgBaseFeePerGas"`
+}
+
+// ChainOverview is the live state of a chain, the heights being nil when
+// they cannot be fetched, with the reasons joined in Error.
+type ChainOverview struct {
+	ChainID    uint64  `json:"chainId"`
+	Name       string  `json:"name"`
+	IndexedTip *uint64 `json:"indexedTip"`
+	NodeHead   *uint64 `json:"nodeHead"`
+	// blocks the indexed tip is behind the node head
+	Lag *uint64 `json:"lag"`
+	// running, stopped or error
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Error struct {
+	Error string `json:"error"`
+}
+
+func newBlock(b *bsBlock) *Block {
+	txCount := b.TxCount
+	if b.TransactionCount != nil {
+		txCount = *b.TransactionCount
+	}
+	return &Block{
+		Number:           b.Height,
+		Hash:             b.Hash,
+		ParentHash:       b.ParentHash,
+		Timestamp:        b.Timestamp,
+		Miner:            checksum(b.Miner.Hash),
+		Size:             b.Size,
+		GasUsed:          b.GasUsed,
+		GasLimit:         b.GasLimit,
+		TransactionCount: txCount,
+		BaseFeePerGas:    b.BaseFeePerGas,
+	}
+}
+
+func newWithdrawal(w *bsWithdrawal) *Withdrawal {
+	return &Withdrawal{
+		Index:          w.Index,
+		ValidatorIndex: w.ValidatorIndex,
+		Address:        checksum(w.Receiver.Hash),
+		Amount:         w.Amount,
+	}
+}
+
+func newInternalTransaction(call *bsInternalTransaction) *InternalTransaction {
+	var to *string
+	switch {
+	case call.To != nil:
+		address := checksum(call.To.Hash)
+		to = &address
+	case call.CreatedContract != nil:
+		address := checksum(call.CreatedContract.Hash)
+		to = &address
+	}
+	return &InternalTransaction{
+		Index:    call.Index,
+		Type:     call.Type,
+		CallType: call.CallType,
+		From:     checksum(call.From.Hash),
+		To:       to,
+		Value:    call.Value,
+		Success:  call.Success,
+		Error:    call.Error,
+	}
+}
+
+func newPendingTransaction(tx *mempool.Tx) *PendingTransaction {
+	var to *string
+	if tx.To != nil {
+		address := tx.To.Hex()
+		to = &address
+	}
+	return &PendingTransaction{
+		Hash:                 tx.Hash.Hex(),
+		From:                 tx.From.Hex(),
+		To:                   to,
+		Nonce:                tx.Nonce,
+		Value:                bigString(tx.Value),
+		Gas:                  strconv.FormatUint(tx.Gas, 10),
+		GasPrice:             optionalBigString(tx.GasPrice),
+		Type:                 tx.Type,
+		MaxFeePerGas:         optionalBigString(tx.MaxFeePerGas),
+		MaxPriorityFeePerGas: optionalBigString(tx.MaxPriorityFeePerGas),
+		Input:                hexutil.Encode(tx.Input),
+		Status:               tx.Status,
+		FirstSeen:            tx.FirstSeen,
+	}
+}
+
+func bigString(n *big.Int) string {
+	if n == nil {
+		return "0"
+	}
+	return n.String()
+}
+
+func optionalBigString(n *big.Int) *string {
+	if n == nil {
+		return nil
+	}
+	s := n.String()
+	return &s
+}
+
+func newTransaction(tx *bsTransaction) *Transaction {
+	blockNumber := txBlockNumber(tx)
+	// Blockscout replaces the gas price with the effective one of the receipt
+	// once the transaction is mined
+	var effectiveGasPrice *string
+	if blockNumber != nil && tx.GasPrice != "" {
+		effectiveGasPrice = &tx.GasPrice
+	}
+	var to *string
+	if tx.To != nil {
+		address := checksum(tx.To.Hash)
+		to = &address
+	}
+	return &Transaction{
+		Hash:                 tx.Hash,
+		BlockNumber:          blockNumber,
+		Index:                tx.Position,
+		Timestamp:            tx.Timestamp,
+		From:                 checksum(tx.From.Hash),
+		To:                   to,
+		Value:                tx.Value,
+		Nonce:                tx.Nonce,
+		Gas:                  tx.GasLimit,
+		GasUsed:              tx.GasUsed,
+		GasPrice:             tx.GasPrice,
+		Input:                tx.RawInput,
+		Type:                 tx.Type,
+		MaxFeePerGas:         tx.MaxFeePerGas,
+		MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas,
+		EffectiveGasPrice:    effectiveGasPrice,
+		Status:               txStatus(tx),
+	}
+}
+
+func newLog(l *bsLog) *Log {
+	topics := make([]string, 0, len(l.Topics))
+	for _, topic := range l.Topics {
+		if topic != nil {
+			topics = append(topics, *topic)
+		}
+	}
+	return &Log{
+		Index:           l.Index,
+		Address:         checksum(l.Address.Hash),
+		Topics:          topics,
+		Data:            l.Data,
+		BlockNumber:     l.BlockNumber,
+		TransactionHash: l.TransactionHash,
+	}
+}
+
+func newToken(t *bsToken, address common.Address) *Token {
+	token := &Token{
+		Address:     address.Hex(),
+		Name:        t.Name,
+		Symbol:      t.Symbol,
+		TotalSupply: t.TotalSupply,
+		Type:        t.Type,
+	}
+	// tokens may report any decimals, the nonsensical ones are left out
+	if t.Decimals != nil {
+		if decimals, err := strconv.ParseUint(*t.Decimals, 10, 8); err == nil {
+			d := uint8(decimals)
+			token.Decimals = &d
+		}
+	}
+	return token
+}
+
+// checksum returns the address in its EIP-55 form, anything else as is.
+func checksum(address string) string {
+	if !common.IsHexAddress(address) {
+		return address
+	}
+	return common.HexToAddress(address).Hex()
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const erc721 = "ERC-721"
+
+// Blockscout transfer types, ERC-721 transfers from and to the zero address
+// are mints and burns
+var nftTransferTypes = map[string]string{
+	"token_transfer": "transfer",
+	"token_minting":  "mint",
+	"token_spawning": "mint",
+	"token_burning":  "burn",
+}
+
+type bsTokenInstance struct {
+	Owner *bsAddress `json:"owner"`
+}
+
+type bsTokenTransfer struct {
+	TransactionHash string     `json:"transaction_hash"`
+	BlockNumber     *uint64    `json:"block_number"`
+	LogIndex        uint64     `json:"log_index"`
+	Timestamp       *time.Time `json:"timestamp"`
+	From            bsAddress  `json:"from"`
+	To              bsAddress  `json:"to"`
+	Type            string     `json:"type"`
+}
+
+// handleNFT returns the current owner of an ERC-721 token and a page of its
+// transfers, most recent first.
+func (s *Server) handleNFT(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	address, ok := pathAddress(w, r)
+	if !ok {
+		return
+	}
+	tokenID, ok := new(big.Int).SetString(r.PathValue("tokenID"), 10)
+	if !ok || tokenID.Sign() < 0 || tokenID.BitLen() > 256 {
+		writeError(w, http.StatusBadRequest, "invalid token id, expected a decimal uint256")
+		return
+	}
+	page, ok := pageCursor(w, r)
+	if !ok {
+		return
+	}
+
+	// Blockscout tells ERC-721 and ERC-20 Transfer events apart by their
+	// number of indexed topics, the token id being indexed for ERC-721
+	collection, err := chain.token(r.Context(), address)
+	if err != nil {
+		s.writeBackendError(w, err, fmt.Sprintf("token %s is not indexed", address.Hex()))
+		return
+	}
+	if collection.Type != erc721 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("token %s is an %s token, not an ERC-721 collection", address.Hex(), collection.Type))
+		return
+	}
+
+	nft, next, err := chain.nft(r.Context(), collection, tokenID, page)
+	if err != nil {
+		s.writeBackendError(w, err, fmt.Sprintf("token %s of %s is not indexed", tokenID, address.Hex()))
+		return
+	}
+	if next != nil {
+		nft.NextCursor = encodePageCursor(next)
+	}
+	writeJSON(w, http.StatusOK, nft)
+}
+
+// nft returns the token of the collection with its current owner, along with
+// a page of its transfers and the params of the next page, if any.
+func (b *backend) nft(ctx context.Context, collection *Token, tokenID *big.Int, page url.Values) (*NFT, url.Values, error) {
+	path := fmt.Sprintf("/tokens/%s/instances/%s", collection.Address, tokenID)
+	var instance bsTokenInstance
+	if err := b.get(ctx, path, nil, &instance); err != nil {
+		return nil, nil, err
+	}
+	var resp bsPage[*bsTokenTransfer]
+	err := b.get(ctx, path+"/transfers", page, &resp)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, nil, err
+	}
+
+	nft := &NFT{
+		Collection: collection,
+		TokenID:    tokenID.String(),
+		Transfers:  make([]*NFTTransfer, len(resp.Items)),
+	}
+	if instance.Owner != nil {
+		owner := checksum(instance.Owner.Hash)
+		nft.Owner = &owner
+	}
+	for i, transfer := range resp.Items {
+		nft.Transfers[i] = newNFTTransfer(transfer)
+	}
+	return nft, pageParams(resp.NextPageParams), nil
+}
+
+func newNFTTransfer(t *bsTokenTransfer) *NFTTransfer {
+	kind, ok := nftTransferTypes[t.Type]
+	if !ok {
+		kind = t.Type
+	}
+	return &NFTTransfer{
+		TransactionHash: t.TransactionHash,
+		BlockNumber:     t.BlockNumber,
+		LogIndex:        t.LogIndex,
+		Timestamp:       t.Timestamp,
+		From:            checksum(t.From.Hash),
+		To:              checksum(t.To.Hash),
+		Type:            kind,
+	}
+}
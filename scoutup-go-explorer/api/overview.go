@@ -0,0 +1,112 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const overviewTimeout = 5 * time.Second
+
+// Indexers reports the state of the chains' indexers, one of running, stopped
+// or error along with the error.
+type Indexers interface {
+	IndexerStatus(chainID uint64) (string, error)
+}
+
+// SetIndexers sets where the indexing status of the chains comes from, the
+// indexers being started after the server.
+func (s *Server) SetIndexers(indexers Indexers) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.indexers = indexers
+}
+
+// handleChains summarizes every chain, a chain whose node or Blockscout is
+// unreachable is reported with the error instead of failing the request, as
+// are the chains not started yet.
+func (s *Server) handleChains(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), overviewTimeout)
+	defer cancel()
+
+	s.mu.RLock()
+	indexers := s.indexers
+	s.mu.RUnlock()
+
+	chains := s.backends()
+	pending := s.pendingChains()
+	overviews := make([]*ChainOverview, 0, len(chains)+len(pending))
+	for name, reason := range pending {
+		overviews = append(overviews, &ChainOverview{Name: name, Status: "stopped", Error: fmt.Sprintf("not started: %s", reason)})
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for chainID, chain := range chains {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			overview := chainOverview(ctx, chainID, chain, indexers)
+			mu.Lock()
+			defer mu.Unlock()
+			overviews = append(overviews, overview)
+		}()
+	}
+	wg.Wait()
+	slices.SortFunc(overviews, func(a, b *ChainOverview) int {
+		return cmp.Or(cmp.Compare(a.ChainID, b.ChainID), cmp.Compare(a.Name, b.Name))
+	})
+	writeJSON(w, http.StatusOK, overviews)
+}
+
+func chainOverview(ctx context.Context, chainID uint64, chain *backend, indexers Indexers) *ChainOverview {
+	overview := &ChainOverview{ChainID: chainID, Name: chain.name, Status: "stopped"}
+	var errs []string
+	if indexers != nil {
+		status, err := indexers.IndexerStatus(chainID)
+		overview.Status = status
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("indexer: %s", err))
+		}
+	}
+
+	var wg sync.WaitGroup
+	var headErr, tipErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		var head hexutil.Uint64
+		if headErr = chain.call(ctx, &head, "eth_blockNumber"); headErr == nil {
+			overview.NodeHead = (*uint64)(&head)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var tip uint64
+		if tip, tipErr = chain.indexedHeight(ctx); tipErr == nil {
+			overview.IndexedTip = &tip
+		}
+	}()
+	wg.Wait()
+
+	if headErr != nil {
+		errs = append(errs, fmt.Sprintf("cannot get the node head: %s", headErr))
+	}
+	if tipErr != nil && !errors.Is(tipErr, errNotIndexed) {
+		errs = append(errs, fmt.Sprintf("cannot get the indexed tip: %s", tipErr))
+	}
+	if overview.NodeHead != nil && overview.IndexedTip != nil {
+		// the node may be behind the indexed data for a moment after a reorg
+		lag := *overview.NodeHead - min(*overview.IndexedTip, *overview.NodeHead)
+		overview.Lag = &lag
+	}
+	overview.Error = strings.Join(errs, "; ")
+	return overview
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	statusSuccess = "success"
+	statusFailed  = "failed"
+
+	revertError  = "error"
+	revertPanic  = "panic"
+	revertCustom = "custom"
+)
+
+var (
+	// Error(string) and Panic(uint256)
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+)
+
+// txStatus maps the receipt status kept by Blockscout, nil while pending.
+func txStatus(tx *bsTransaction) *string {
+	var status string
+	switch {
+	case tx.Status == nil:
+		return nil
+	case *tx.Status == "ok":
+		status = statusSuccess
+	default:
+		status = statusFailed
+	}
+	return &status
+}
+
+// revertReason returns what the failed transaction reverted with, as fetched
+// by Blockscout or, when it has none, by replaying the transaction with
+// eth_call on top of its parent block. The replay does not account for the
+// transactions before it in the block, so the reason may be left without data
+// when the replay does not revert.
+func (b *backend) revertReason(ctx context.Context, tx *bsTransaction) *RevertReason {
+	data, ok := blockscoutRevertData(tx.RevertReason)
+	if !ok {
+		var err error
+		data, err = b.replayRevert(ctx, tx)
+		if err != nil {
+			b.log.Debug("Cannot replay the failed transaction", "hash", tx.Hash, "err", err)
+		}
+	}
+	if len(data) == 0 {
+		// e.g. out of gas, which Blockscout keeps as the result
+		reason := &RevertReason{Data: "0x"}
+		if tx.Result != "" && tx.Result != "Reverted" && tx.Result != "error" {
+			reason.Message = &tx.Result
+		}
+		return reason
+	}
+
+	var to *common.Address
+	if tx.To != nil {
+		address := common.HexToAddress(tx.To.Hash)
+		to = &address
+	}
+	return b.decodeRevert(data, to)
+}
+
+// blockscoutRevertData returns the revert data Blockscout keeps as
+// {"raw": "0x..."}, or as a plain hex string with older versions.
+func blockscoutRevertData(raw json.RawMessage) ([]byte, bool) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, false
+	}
+	var reason struct {
+		Raw string `json:"raw"`
+	}
+	if err := json.Unmarshal(raw, &reason); err != nil {
+		if err := json.Unmarshal(raw, &reason.Raw); err != nil {
+			return nil, false
+		}
+	}
+	data, err := hexutil.Decode(reason.Raw)
+	return data, err == nil
+}
+
+// replayRevert returns the revert data of the transaction replayed on top of
+// its parent block, nil when the replay does not revert.
+func (b *backend) replayRevert(ctx context.Context, tx *bsTransaction) ([]byte, error) {
+	blockNumber := txBlockNumber(tx)
+	if blockNumber == nil || *blockNumber == 0 {
+		return nil, errors.New("not in a replayable block")
+	}
+	client, err := b.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	call := map[string]interface{}{
+		"from": common.HexToAddress(tx.From.Hash),
+		"data": tx.RawInput,
+	}
+	if tx.To != nil {
+		call["to"] = common.HexToAddress(tx.To.Hash)
+	}
+	if value, ok := new(big.Int).SetString(tx.Value, 10); ok {
+		call["value"] = (*hexutil.Big)(value)
+	}
+	if gas, ok := new(big.Int).SetString(tx.GasLimit, 10); ok && gas.IsUint64() {
+		call["gas"] = hexutil.Uint64(gas.Uint64())
+	}
+
+	var result hexutil.Bytes
+	err = client.CallContext(ctx, &result, "eth_call", call, hexutil.EncodeUint64(*blockNumber-1))
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, err
+	}
+	hex, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, err
+	}
+	return hexutil.Decode(hex)
+}
+
+// decodeRevert decodes the standard Error(string) and Panic(uint256) reasons,
+// and the custom errors of the contracts with a known ABI.
+func (b *backend) decodeRevert(data []byte, to *common.Address) *RevertReason {
+	reason := &RevertReason{Data: hexutil.Encode(data), Kind: revertCustom}
+	if len(data) < 4 {
+		return reason
+	}
+	switch {
+	case bytes.Equal(data[:4], errorSelector):
+		reason.Kind = revertError
+	case bytes.Equal(data[:4], panicSelector):
+		reason.Kind = revertPanic
+	}
+
+	if reason.Kind != revertCustom {
+		if message, err := abi.UnpackRevert(data); err == nil {
+			reason.Message = &message
+		}
+		return reason
+	}
+	if to == nil {
+		return reason
+	}
+	if contract := b.abis.get(*to); contract != nil {
+		if customErr, err := contract.ErrorByID([4]byte(data[:4])); err == nil {
+			reason.Message = &customErr.Sig
+		}
+	}
+	return reason
+}
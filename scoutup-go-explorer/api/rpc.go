@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/blockscout/scoutup/rpcclient"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// node returns the client of the chain's RPC, it is dialed on first use so
+// that an unreachable node does not prevent the API from starting.
+func (b *backend) node(ctx context.Context) (*rpcclient.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rpc == nil {
+		client, err := b.chain.DialRPC(ctx, b.log)
+		if err != nil {
+			return nil, err
+		}
+		b.rpc = client
+	}
+	return b.rpc, nil
+}
+
+// balance returns the balance of the address at block, "latest" when empty.
+func (b *backend) balance(ctx context.Context, address common.Address, block string) (*big.Int, error) {
+	client, err := b.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if block == "" {
+		block = "latest"
+	}
+
+	var balance hexutil.Big
+	if err := client.CallContext(ctx, &balance, "eth_getBalance", address, block); err != nil {
+		return nil, err
+	}
+	return balance.ToInt(), nil
+}
+
+// call makes an RPC request to the chain's node.
+func (b *backend) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	client, err := b.node(ctx)
+	if err != nil {
+		return err
+	}
+	return client.CallContext(ctx, result, method, args...)
+}
+
+func (b *backend) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopWatching != nil {
+		b.stopWatching()
+	}
+	if b.rpc != nil {
+		b.rpc.Close()
+		b.rpc = nil
+	}
+}
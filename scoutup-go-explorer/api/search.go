@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	searchBlock       = "block"
+	searchTransaction = "transaction"
+	searchAddress     = "address"
+	searchToken       = "token"
+)
+
+var (
+	decimalRegex = regexp.MustCompile(`^[0-9]+$`)
+	hexRegex     = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+)
+
+// handleSearch resolves q to the indexed resources it may refer to: a block
+// number, a block or transaction hash, or an address.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if strings.HasPrefix(q, "0X") {
+		q = "0x" + q[2:]
+	}
+	if msg := searchQueryError(q); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	prefix := fmt.Sprintf("/chains/%d", chain.chain.ChainID)
+	var results []*SearchResult
+	var err error
+	switch {
+	case decimalRegex.MatchString(q):
+		results, err = searchBlockNumber(r, chain, prefix, q)
+	case txHashRegex.MatchString(q):
+		results, err = searchHash(r, chain, prefix, q)
+	default:
+		results, err = searchAddressOrToken(r, chain, prefix, common.HexToAddress(q))
+	}
+	if err != nil {
+		s.writeBackendError(w, err, "")
+		return
+	}
+	writeJSON(w, http.StatusOK, &SearchResults{Query: q, Results: results})
+}
+
+// searchQueryError describes why q cannot be searched, empty when it can.
+func searchQueryError(q string) string {
+	const expected = "expected a block number, a 0x-prefixed 32-byte block or transaction hash or a 0x-prefixed 20-byte address"
+	switch {
+	case q == "":
+		return "missing q, " + expected
+	case decimalRegex.MatchString(q):
+		if _, err := strconv.ParseUint(q, 10, 64); err != nil {
+			return "block number out of range"
+		}
+		return ""
+	case !strings.HasPrefix(q, "0x"):
+		if hexRegex.MatchString(q) && (len(q) == 64 || len(q) == 40) {
+			return "missing 0x prefix, " + expected
+		}
+		return "unrecognized query, " + expected
+	}
+
+	hex := q[2:]
+	switch {
+	case !hexRegex.MatchString(hex):
+		return "invalid hex, " + expected
+	case len(hex) != 64 && len(hex) != 40:
+		return fmt.Sprintf("%d-byte hex value, %s", (len(hex)+1)/2, expected)
+	}
+	return ""
+}
+
+func searchBlockNumber(r *http.Request, chain *backend, prefix, q string) ([]*SearchResult, error) {
+	number, _ := strconv.ParseUint(q, 10, 64)
+	_, err := chain.cachedBlock(r.Context(), number)
+	if errors.Is(err, errNotFound) {
+		return []*SearchResult{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []*SearchResult{{Type: searchBlock, ID: q, Path: fmt.Sprintf("%s/blocks/%d", prefix, number)}}, nil
+}
+
+// searchHash looks the hash up both as a transaction and as a block hash,
+// returning all the matches.
+func searchHash(r *http.Request, chain *backend, prefix, hash string) ([]*SearchResult, error) {
+	results := []*SearchResult{}
+	tx, err := chain.transaction(r.Context(), hash)
+	switch {
+	case err == nil:
+		results = append(results, &SearchResult{Type: searchTransaction, ID: tx.Hash, Path: prefix + "/tx/" + tx.Hash})
+	case !errors.Is(err, errNotFound):
+		return nil, err
+	}
+
+	block, err := chain.blockByHash(r.Context(), hash)
+	switch {
+	case err == nil:
+		results = append(results, &SearchResult{Type: searchBlock, ID: strconv.FormatUint(block.Height, 10), Path: fmt.Sprintf("%s/blocks/%d", prefix, block.Height)})
+	case !errors.Is(err, errNotFound):
+		return nil, err
+	}
+	return results, nil
+}
+
+// searchAddressOrToken always resolves to the address, its balance is served
+// whether indexed or not, and also to the token when the address is a watched one.
+func searchAddressOrToken(r *http.Request, chain *backend, prefix string, address common.Address) ([]*SearchResult, error) {
+	results := []*SearchResult{{Type: searchAddress, ID: address.Hex(), Path: prefix + "/address/" + address.Hex()}}
+	if !chain.chain.WatchesToken(address) {
+		return results, nil
+	}
+	_, err := chain.token(r.Context(), address)
+	switch {
+	case err == nil:
+		results = append(results, &SearchResult{Type: searchToken, ID: address.Hex(), Path: prefix + "/token/" + address.Hex() + "/holders"})
+	case !errors.Is(err, errNotFound):
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blockscout/scoutup/config"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+// Server serves the indexed data of all the chains over a REST API, proxying
+// the requests to the Blockscout backend of the requested chain.
+type Server struct {
+	log   log.Logger
+	addr  string
+	cache *cache
+
+	// chains are added and removed on config reloads
+	mu     sync.RWMutex
+	chains map[uint64]*backend
+	// the configured chains not served yet by name, with the reason
+	pending map[string]error
+	// the mempool watchers run until the server stops
+	watchCtx context.Context
+	cancel   context.CancelFunc
+
+	listener net.Listener
+	http     *http.Server
+	// set when serving HTTPS
+	cert *certificate
+	// nil until SetIndexers is called
+	indexers Indexers
+	// required by the admin endpoints, disabled when empty
+	adminToken string
+}
+
+// NewServer returns a server listening on addr that caches up to cacheSize
+// blocks and as many transactions, nothing when cacheSize is not positive.
+func NewServer(log log.Logger, addr string, cacheSize int, configs []*config.BlockscoutConfig) *Server {
+	cache := newCache(cacheSize)
+	chains := make(map[uint64]*backend)
+	for _, cfg := range configs {
+		chains[cfg.ChainID] = newBackend(log, cfg, cache)
+	}
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	s := &Server{log: log, addr: addr, chains: chains, cache: cache, watchCtx: watchCtx, cancel: cancel}
+	s.http = &http.Server{
+		Handler:           s.routes(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	return s
+}
+
+// Start binds the listen address, so that a taken port fails the start, and
+// serves in the background.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", s.addr, err)
+	}
+	s.listener = listener
+	if s.cert != nil {
+		listener = tls.NewListener(listener, s.http.TLSConfig)
+	}
+	s.log.Info("API server started", "addr", s.listener.Addr().String(), "tls", s.cert != nil)
+
+	for _, chain := range s.backends() {
+		s.startWatching(chain)
+	}
+
+	go func() {
+		if err := s.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Error("API server failed", "err", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	if s.listener == nil {
+		return nil
+	}
+	s.cancel()
+	err := s.http.Shutdown(ctx)
+	for _, chain := range s.backends() {
+		chain.close()
+	}
+	return err
+}
+
+// AddChain starts serving the chain, e.g. one added by a config reload.
+func (s *Server) AddChain(cfg *config.BlockscoutConfig) {
+	chain := newBackend(s.log, cfg, s.cache)
+	s.mu.Lock()
+	s.chains[cfg.ChainID] = chain
+	delete(s.pending, cfg.Name)
+	s.mu.Unlock()
+	if s.listener != nil {
+		s.startWatching(chain)
+	}
+}
+
+// RemoveChain stops serving the chain and drops its cached data.
+func (s *Server) RemoveChain(chainID uint64) {
+	s.mu.Lock()
+	chain, ok := s.chains[chainID]
+	delete(s.chains, chainID)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	chain.close()
+	s.cache.invalidate(chainID, 0)
+}
+
+func (s *Server) lookup(chainID uint64) (*backend, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	chain, ok := s.chains[chainID]
+	return chain, ok
+}
+
+func (s *Server) backends() map[uint64]*backend {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return maps.Clone(s.chains)
+}
+
+// SetAdminToken sets the bearer token of the admin endpoints, an empty one
+// disables them.
+func (s *Server) SetAdminToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.adminToken = token
+}
+
+// authorizeAdmin checks the bearer token of an admin request, writing the
+// error response when it is missing or wrong.
+func (s *Server) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.RLock()
+	token := s.adminToken
+	s.mu.RUnlock()
+	if token == "" {
+		writeError(w, http.StatusForbidden, "admin endpoints are disabled, set adminToken to enable them")
+		return false
+	}
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "invalid or missing admin token")
+		return false
+	}
+	return true
+}
+
+// SetPending replaces the configured chains that are not started yet, by name
+// along with the reason, e.g. the ones held back until their RPC answers.
+// They are listed as not ready until AddChain serves them.
+func (s *Server) SetPending(pending map[string]error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = maps.Clone(pending)
+}
+
+func (s *Server) pendingChains() map[string]error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return maps.Clone(s.pending)
+}
+
+func (s *Server) startWatching(chain *backend) {
+	if chain.mempool == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(s.watchCtx)
+	chain.mu.Lock()
+	chain.stopWatching = cancel
+	chain.mu.Unlock()
+	go chain.mempool.Run(ctx)
+}
+
+// Addr returns the address the server listens on, once started.
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.addr
+	}
+	return s.listener.Addr().String()
+}
+
+// Reorg drops the cached blocks and the block stats of the chain from the
+// lowest replaced height up, as Blockscout refetches them.
+func (s *Server) Reorg(chainID, from uint64) {
+	s.cache.invalidate(chainID, from)
+	if chain, ok := s.lookup(chainID); ok {
+		chain.stats.invalidate(from)
+	}
+}
@@ -0,0 +1,320 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStatsWindow = 24 * time.Hour
+	// the summaries of older blocks are dropped, which bounds the window
+	maxStatsWindow = 7 * 24 * time.Hour
+)
+
+// blockSummary is what the stats need of an indexed block.
+type blockSummary struct {
+	number    uint64
+	timestamp time.Time
+	gasUsed   uint64
+	txCount   uint64
+	// nil before London
+	baseFee *big.Int
+}
+
+// blockStats keeps the summaries of a chain's recent consensus blocks, so
+// that every request only fetches the blocks indexed since the previous one.
+type blockStats struct {
+	// held while walking the pages of Blockscout, which only takes mu to
+	// commit every page, so the summaries stay readable meanwhile
+	walk sync.Mutex
+
+	mu sync.Mutex
+	// ordered by number, without gaps
+	blocks []*blockSummary
+	// the oldest summary is the first indexed block
+	complete bool
+	// bumped by invalidate, the pages fetched before are not committed
+	generation uint64
+}
+
+// invalidate drops the summaries of the blocks from the height up.
+func (s *blockStats) invalidate(from uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, _ := slices.BinarySearchFunc(s.blocks, from, func(b *blockSummary, number uint64) int {
+		return cmp.Compare(b.number, number)
+	})
+	s.blocks = s.blocks[:i]
+	if len(s.blocks) == 0 {
+		s.complete = false
+	}
+	s.generation++
+}
+
+// newest returns the newest summary, nil when there is none.
+func (s *blockStats) newest() (*blockSummary, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.blocks) == 0 {
+		return nil, s.generation
+	}
+	return s.blocks[len(s.blocks)-1], s.generation
+}
+
+// oldest returns the number of the oldest summary, when it is not the first
+// indexed block and not older than start.
+func (s *blockStats) oldest(start time.Time) (uint64, uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.blocks) == 0 || s.complete || s.blocks[0].timestamp.Before(start) {
+		return 0, 0, false
+	}
+	return s.blocks[0].number, s.generation, true
+}
+
+// appendNew commits the blocks fetched from the newest one down, which replace
+// the summaries unless they reach the newest one.
+func (s *blockStats) appendNew(generation uint64, fetched []*blockSummary, reachedLast, reachedFirst bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(fetched) == 0 || s.generation != generation {
+		return
+	}
+	if !reachedLast {
+		// the summaries are too old to be contiguous with the fetched blocks
+		s.blocks = nil
+		s.complete = reachedFirst
+	}
+	slices.Reverse(fetched)
+	s.blocks = append(s.blocks, fetched...)
+}
+
+// prependOld commits the blocks below the oldest summary, oldest first.
+func (s *blockStats) prependOld(generation uint64, blocks []*blockSummary, complete bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generation != generation {
+		return false
+	}
+	s.blocks = append(blocks, s.blocks...)
+	s.complete = complete
+	return true
+}
+
+func (s *blockStats) trim(cutoff time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, _ := slices.BinarySearchFunc(s.blocks, cutoff, func(s *blockSummary, cutoff time.Time) int {
+		return s.timestamp.Compare(cutoff)
+	})
+	if i > 0 {
+		s.blocks = slices.Clone(s.blocks[i:])
+		s.complete = false
+	}
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	chain, ok := s.chain(w, r)
+	if !ok {
+		return
+	}
+	window := defaultStatsWindow
+	if param := r.URL.Query().Get("window"); param != "" {
+		var err error
+		window, err = time.ParseDuration(param)
+		if err != nil || window <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid window, expected a positive duration such as 1h or 24h")
+			return
+		}
+		if window > maxStatsWindow {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("window must not exceed %s", maxStatsWindow))
+			return
+		}
+	}
+
+	stats, err := chain.blockStats(r.Context(), window)
+	if err != nil {
+		s.writeBackendError(w, err, "")
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// blockStats aggregates the consensus blocks indexed within the window up to now.
+// While another request walks the pages of Blockscout, the blocks it summarized
+// so far are aggregated, the response tells which ones.
+func (b *backend) blockStats(ctx context.Context, window time.Duration) (*Stats, error) {
+	now := time.Now()
+	start := now.Add(-window)
+	if b.stats.walk.TryLock() {
+		err := b.fetchSummaries(ctx, start)
+		b.stats.trim(now.Add(-maxStatsWindow))
+		b.stats.walk.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b.stats.mu.Lock()
+	defer b.stats.mu.Unlock()
+	i, _ := slices.BinarySearchFunc(b.stats.blocks, start, func(s *blockSummary, start time.Time) int {
+		return s.timestamp.Compare(start)
+	})
+	return newStats(window, b.stats.blocks[i:]), nil
+}
+
+// fetchSummaries summarizes the blocks of the window not summarized yet. Every
+// page is kept once fetched, a failed or cancelled walk goes on from there.
+func (b *backend) fetchSummaries(ctx context.Context, start time.Time) error {
+	if err := b.fetchNewSummaries(ctx, start); err != nil {
+		return err
+	}
+	return b.fetchOldSummaries(ctx, start)
+}
+
+// fetchNewSummaries walks the blocks from the newest down to the last summarized
+// one. Without summaries only the newest page is fetched, fetchOldSummaries
+// walks the blocks below it.
+func (b *backend) fetchNewSummaries(ctx context.Context, start time.Time) error {
+	last, generation := b.stats.newest()
+
+	var fetched []*blockSummary
+	reachedLast, reachedFirst := false, false
+	defer func() { b.stats.appendNew(generation, fetched, reachedLast, reachedFirst) }()
+	page := url.Values{}
+	for page != nil && !reachedLast {
+		blocks, next, err := b.consensusBlocks(ctx, page)
+		if err != nil {
+			return err
+		}
+		for _, block := range blocks {
+			if last != nil && block.number <= last.number {
+				reachedLast = true
+				break
+			}
+			fetched = append(fetched, block)
+		}
+		page = next
+		reachedFirst = next == nil
+		if last == nil || (len(fetched) > 0 && fetched[len(fetched)-1].timestamp.Before(start)) {
+			break
+		}
+	}
+	return nil
+}
+
+// fetchOldSummaries walks the blocks below the oldest summarized one down to start.
+func (b *backend) fetchOldSummaries(ctx context.Context, start time.Time) error {
+	for {
+		oldest, generation, ok := b.stats.oldest(start)
+		if !ok {
+			return nil
+		}
+		page := url.Values{"block_number": {strconv.FormatUint(oldest, 10)}}
+		blocks, next, err := b.consensusBlocks(ctx, page)
+		if err != nil {
+			return err
+		}
+		// the page holds the blocks below the oldest one, newest first
+		blocks = slices.DeleteFunc(blocks, func(s *blockSummary) bool { return s.number >= oldest })
+		slices.Reverse(blocks)
+		if !b.stats.prependOld(generation, blocks, next == nil || len(blocks) == 0) {
+			// a reorg dropped the summaries meanwhile
+			return nil
+		}
+	}
+}
+
+// consensusBlocks returns a page of the canonical blocks, newest first.
+func (b *backend) consensusBlocks(ctx context.Context, page url.Values) ([]*blockSummary, url.Values, error) {
+	query := url.Values{"type": {"block"}}
+	for key, values := range page {
+		query[key] = values
+	}
+	var resp bsPage[*bsBlock]
+	if err := b.get(ctx, "/blocks", query, &resp); err != nil {
+		return nil, nil, err
+	}
+
+	summaries := make([]*blockSummary, 0, len(resp.Items))
+	for _, block := range resp.Items {
+		summary, err := newBlockSummary(block)
+		if err != nil {
+			return nil, nil, fmt.Errorf("block %d: %w", block.Height, err)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, pageParams(resp.NextPageParams), nil
+}
+
+func newBlockSummary(block *bsBlock) (*blockSummary, error) {
+	gasUsed, err := strconv.ParseUint(block.GasUsed, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas used %q", block.GasUsed)
+	}
+	summary := &blockSummary{
+		number:    block.Height,
+		timestamp: block.Timestamp,
+		gasUsed:   gasUsed,
+		txCount:   newBlock(block).TransactionCount,
+	}
+	if block.BaseFeePerGas != nil {
+		baseFee, ok := new(big.Int).SetString(*block.BaseFeePerGas, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid base fee %q", *block.BaseFeePerGas)
+		}
+		summary.baseFee = baseFee
+	}
+	return summary, nil
+}
+
+func newStats(window time.Duration, blocks []*blockSummary) *Stats {
+	stats := &Stats{
+		Window:        window.String(),
+		BlockCount:    uint64(len(blocks)),
+		AvgGasUsed:    "0",
+		MedianGasUsed: "0",
+		MaxGasUsed:    "0",
+	}
+	if len(blocks) == 0 {
+		return stats
+	}
+	stats.FromBlock = &blocks[0].number
+	stats.ToBlock = &blocks[len(blocks)-1].number
+
+	gasUsed := make([]uint64, len(blocks))
+	totalGas := new(big.Int)
+	totalBaseFee := new(big.Int)
+	baseFeeBlocks := int64(0)
+	for i, block := range blocks {
+		gasUsed[i] = block.gasUsed
+		totalGas.Add(totalGas, new(big.Int).SetUint64(block.gasUsed))
+		stats.TransactionCount += block.txCount
+		if block.baseFee != nil {
+			totalBaseFee.Add(totalBaseFee, block.baseFee)
+			baseFeeBlocks++
+		}
+	}
+	slices.Sort(gasUsed)
+	median := new(big.Int).SetUint64(gasUsed[len(gasUsed)/2])
+	if len(gasUsed)%2 == 0 {
+		median.Add(median, new(big.Int).SetUint64(gasUsed[len(gasUsed)/2-1]))
+		median.Rsh(median, 1)
+	}
+
+	stats.AvgGasUsed = totalGas.Div(totalGas, big.NewInt(int64(len(blocks)))).String()
+	stats.MedianGasUsed = median.String()
+	stats.MaxGasUsed = strconv.FormatUint(gasUsed[len(gasUsed)-1], 10)
+	if baseFeeBlocks > 0 {
+		stats.AvgBaseFeePerGas = optionalBigString(totalBaseFee.Div(totalBaseFee, big.NewInt(baseFeeBlocks)))
+	}
+	return stats
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync/atomic"
+)
+
+// certificate is the TLS certificate of the server, replaced on reloads while
+// the connections already established keep the one they started with.
+type certificate struct {
+	certPath, keyPath string
+	current           atomic.Pointer[tls.Certificate]
+}
+
+// load reads the certificate and its key, failing when they do not match.
+func (c *certificate) load() error {
+	cert, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+	if err != nil {
+		return fmt.Errorf("cannot load the TLS certificate %s: %w", c.certPath, err)
+	}
+	c.current.Store(&cert)
+	return nil
+}
+
+func (c *certificate) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.current.Load(), nil
+}
+
+// EnableTLS makes the server serve HTTPS with the certificate and key, they
+// are loaded right away so that a wrong pair fails the start.
+func (s *Server) EnableTLS(certPath, keyPath string) error {
+	cert := &certificate{certPath: certPath, keyPath: keyPath}
+	if err := cert.load(); err != nil {
+		return err
+	}
+	s.cert = cert
+	s.http.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: cert.get,
+	}
+	return nil
+}
+
+// ReloadCertificate reads the TLS certificate again, e.g. once renewed. The
+// server keeps the previous one when it cannot be loaded.
+func (s *Server) ReloadCertificate() error {
+	if s.cert == nil {
+		return nil
+	}
+	if err := s.cert.load(); err != nil {
+		return err
+	}
+	s.log.Info("TLS certificate reloaded", "cert", s.cert.certPath)
+	return nil
+}
+
+// TLSEnabled reports whether the server serves HTTPS.
+func (s *Server) TLSEnabled() bool {
+	return s.cert != nil
+}
@@ -0,0 +1,167 @@
+package blockscout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const nodeHeadTimeout = 10 * time.Second
+
+// prepareDatabase makes sure the volume keeping the indexed data exists, so
+// that Blockscout resumes from what it indexed before. With reindex the data
+// is removed first and indexing starts over from the first block. External
+// databases are left to Blockscout, reindexing them is rejected by Validate.
+func (i *Instance) prepareDatabase(ctx context.Context) error {
+	external := i.config.StorageDSN != ""
+
+	// the bundled db service is declared even when unused, so the volume is needed anyway
+	volume := i.config.DatabaseVolume()
+	if i.config.Reindex && !external {
+		i.log.Info("Removing indexed data", "volume", volume)
+		if _, err := i.docker(ctx, "volume", "rm", "--force", volume); err != nil {
+			return fmt.Errorf("cannot remove database volume: %w", err)
+		}
+	}
+	if _, err := i.docker(ctx, "volume", "create", volume); err != nil {
+		return fmt.Errorf("cannot create database volume: %w", err)
+	}
+
+	switch {
+	case external:
+		i.log.Info("Using an external database, the indexed tip is not compared with the node head")
+	case !i.config.Reindex:
+		if err := i.rewindToNodeHead(ctx); err != nil {
+			i.log.Warn("Cannot compare the indexed tip with the node head", "err", err)
+		}
+	}
+	if i.config.RetainedBlocks() > 0 {
+		// the blocks older than the retained ones are not indexed at all
+		if head, err := i.nodeHead(ctx); err != nil {
+			i.log.Warn("Cannot get the node head, indexing from the first block", "err", err)
+		} else if floor := i.retentionFloor(head); floor > i.config.FirstBlock {
+			i.log.Info("Indexing the retained blocks only", "from", floor, "retentionBlocks", i.config.RetainedBlocks())
+		}
+	}
+	return nil
+}
+
+// rewindToNodeHead handles the node being behind the indexed data, e.g. after
+// a resync. Blocks above the node head are marked as non-consensus, which is
+// how Blockscout treats reorged blocks, so that they are refetched.
+func (i *Instance) rewindToNodeHead(ctx context.Context) error {
+	head, err := i.nodeHead(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := i.docker(ctx, "compose", "up", "--detach", "--wait", "db"); err != nil {
+		return err
+	}
+	exists, err := i.psql(ctx, "SELECT to_regclass('public.blocks') IS NOT NULL")
+	if err != nil || exists != "t" {
+		// nothing indexed yet
+		return err
+	}
+	tipStr, err := i.psql(ctx, "SELECT COALESCE(MAX(number), -1) FROM blocks WHERE consensus")
+	if err != nil {
+		return err
+	}
+	tip, err := strconv.ParseInt(tipStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("unexpected indexed tip %q: %w", tipStr, err)
+	}
+
+	if tip < 0 {
+		return nil
+	}
+	i.log.Info("Resuming indexing", "indexedTip", tip, "nodeHead", head)
+	if uint64(tip) <= head {
+		return nil
+	}
+
+	i.log.Warn("Indexed tip is ahead of the node head, rewinding", "indexedTip", tip, "nodeHead", head)
+	_, err = i.psql(ctx, fmt.Sprintf("UPDATE blocks SET consensus = false WHERE number > %d", head))
+	return err
+}
+
+// rewindOrphaned handles a reorg seen by the head monitoring: the blocks above
+// the common ancestor are marked as non-consensus, the way rewindToNodeHead
+// does, and queued along with the canonical ones up to head for the catchup
+// indexer to refetch them.
+func (i *Instance) rewindOrphaned(ctx context.Context, ancestor, head uint64) error {
+	exists, err := i.psql(ctx, "SELECT to_regclass('public.blocks') IS NOT NULL AND to_regclass('public.missing_block_ranges') IS NOT NULL")
+	if err != nil || exists != "t" {
+		// nothing indexed yet
+		return err
+	}
+	out, err := i.psql(ctx, fmt.Sprintf("WITH orphaned AS (UPDATE blocks SET consensus = false WHERE number > %d AND consensus RETURNING number) SELECT COALESCE(MAX(number), -1) FROM orphaned", ancestor))
+	if err != nil {
+		return err
+	}
+	highest, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return fmt.Errorf("unexpected orphaned blocks %q: %w", out, err)
+	}
+	if highest < 0 {
+		// Blockscout did not index past the ancestor yet
+		return nil
+	}
+	i.log.Info("Rewound the orphaned blocks", "from", ancestor+1, "to", highest)
+	return i.queueGaps(ctx, []blockGap{{from: ancestor + 1, to: max(head, uint64(highest))}})
+}
+
+func (i *Instance) nodeHead(ctx context.Context) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, nodeHeadTimeout)
+	defer cancel()
+
+	client, err := i.config.DialRPC(ctx, i.log)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	var head hexutil.Uint64
+	if err := client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return uint64(head), nil
+}
+
+func (i *Instance) psql(ctx context.Context, query string) (string, error) {
+	return i.docker(ctx, "compose", "exec", "-T", "db", "psql", "-U", "blockscout", "-d", "blockscout", "-tAc", query)
+}
+
+// docker runs a docker command in the instance workspace and returns its trimmed output
+func (i *Instance) docker(ctx context.Context, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	if err := i.dockerIO(ctx, nil, &stdout, args...); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// dockerIO runs the docker command in the instance workspace, streaming stdin
+// to it and its output to stdout.
+func (i *Instance) dockerIO(ctx context.Context, stdin io.Reader, stdout io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Env = append(os.Environ(), i.config.DockerComposeEnvs()...)
+	cmd.Dir = i.workspace
+
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
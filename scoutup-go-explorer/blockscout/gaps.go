@@ -0,0 +1,207 @@
+package blockscout
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blockscout/scoutup/metrics"
+)
+
+const (
+	gapScanInterval = 10 * time.Minute
+	gapScanTimeout  = time.Minute
+)
+
+// blockGap is a range of block numbers missing from the indexed blocks.
+type blockGap struct {
+	from, to uint64
+	// whether Blockscout already has the range in its missing block ranges
+	queued bool
+}
+
+func (g blockGap) size() uint64 {
+	return g.to - g.from + 1
+}
+
+// reconcileGaps periodically looks for holes in the indexed blocks, e.g. left
+// by a crash in the middle of a batch, and hands the ones Blockscout does not
+// know about to its catchup indexer, until ctx is cancelled.
+func (i *Instance) reconcileGaps(ctx context.Context) {
+	if i.config.StorageDSN != "" {
+		// the bundled database is the only one reachable through docker
+		i.log.Info("Using an external database, the indexed blocks are not checked for gaps")
+		return
+	}
+	chainMetrics := metrics.ForChain(i.config.ChainID, i.config.Name)
+	var pending []blockGap
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(gapScanInterval):
+		}
+
+		scanCtx, cancel := context.WithTimeout(ctx, gapScanTimeout)
+		gaps, err := i.blockGaps(scanCtx)
+		if err == nil {
+			err = i.queueGaps(scanCtx, gaps)
+		}
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil {
+				i.log.Warn("Cannot scan the indexed blocks for gaps", "err", err)
+			}
+			continue
+		}
+
+		var found []blockGap
+		missing := uint64(0)
+		for _, gap := range gaps {
+			if !gap.queued {
+				found = append(found, gap)
+				missing += gap.size()
+			}
+		}
+		open, filled := openGaps(pending, gaps)
+		pending = append(open, found...)
+		chainMetrics.SetBlockGaps(len(pending))
+		chainMetrics.BlockGapsFilled(filled)
+		if len(found) > 0 {
+			i.log.Warn("Found block gaps, refetching them", "count", len(found), "missingBlocks", missing, "first", found[0].from)
+		}
+		if filled > 0 {
+			i.log.Info("Filled block gaps", "count", filled, "remaining", len(pending))
+		}
+	}
+}
+
+// blockGaps returns the holes between the lowest and the highest indexed
+// blocks. The blocks are only walked when their count does not match the
+// range, which is the case while indexing is still catching up as well.
+func (i *Instance) blockGaps(ctx context.Context) ([]blockGap, error) {
+	exists, err := i.psql(ctx, "SELECT to_regclass('public.blocks') IS NOT NULL AND to_regclass('public.missing_block_ranges') IS NOT NULL")
+	if err != nil || exists != "t" {
+		// nothing indexed yet
+		return nil, err
+	}
+
+	summary, err := i.psql(ctx, fmt.Sprintf("SELECT COALESCE(MIN(number), -1), COALESCE(MAX(number), -1), COUNT(*) FROM blocks WHERE consensus AND number >= %d", i.config.FirstBlock))
+	if err != nil {
+		return nil, err
+	}
+	bounds, err := parseRow(summary, 3)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected indexed range %q: %w", summary, err)
+	}
+	lowest, highest, count := bounds[0], bounds[1], bounds[2]
+	if lowest < 0 || count == highest-lowest+1 {
+		return nil, nil
+	}
+
+	// a hole overlapping a missing block range is left to Blockscout, its
+	// catchup indexer splits the ranges as it fetches them
+	rows, err := i.psql(ctx, fmt.Sprintf(`
+		SELECT s.number + 1, s.next - 1, EXISTS (
+			SELECT 1 FROM missing_block_ranges r
+			WHERE r.from_number >= s.number + 1 AND r.to_number <= s.next - 1
+		)
+		FROM (
+			SELECT number, LEAD(number) OVER (ORDER BY number) AS next
+			FROM blocks WHERE consensus AND number >= %d
+		) s
+		WHERE s.next > s.number + 1
+		ORDER BY s.number`, lowest))
+	if err != nil {
+		return nil, err
+	}
+	var gaps []blockGap
+	for _, row := range strings.Split(rows, "\n") {
+		if row == "" {
+			continue
+		}
+		fields := strings.Split(row, "|")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected gap %q", row)
+		}
+		bounds, err := parseRow(fields[0]+"|"+fields[1], 2)
+		if err != nil || bounds[0] < 0 || bounds[1] < bounds[0] {
+			return nil, fmt.Errorf("unexpected gap %q", row)
+		}
+		gaps = append(gaps, blockGap{from: uint64(bounds[0]), to: uint64(bounds[1]), queued: fields[2] == "t"})
+	}
+	return gaps, nil
+}
+
+// queueGaps adds the gaps Blockscout does not know about to its missing block
+// ranges, which its catchup indexer refetches.
+func (i *Instance) queueGaps(ctx context.Context, gaps []blockGap) error {
+	var values []string
+	for _, gap := range gaps {
+		if !gap.queued {
+			// the ranges go from the higher block down to the lower one
+			values = append(values, fmt.Sprintf("(%d, %d)", gap.to, gap.from))
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	_, err := i.psql(ctx, "INSERT INTO missing_block_ranges (from_number, to_number) VALUES "+strings.Join(values, ", ")+" ON CONFLICT DO NOTHING")
+	return err
+}
+
+// queueBlock hands a single block to the catchup indexer, e.g. one skipped
+// by the head monitoring, unless Blockscout has indexed it already.
+func (i *Instance) queueBlock(ctx context.Context, number uint64) {
+	out, err := i.psql(ctx, fmt.Sprintf(`WITH queued AS (
+		INSERT INTO missing_block_ranges (from_number, to_number)
+		SELECT %[1]d, %[1]d WHERE NOT EXISTS (SELECT 1 FROM blocks WHERE number = %[1]d AND consensus)
+		ON CONFLICT DO NOTHING RETURNING 1
+	) SELECT COUNT(*) FROM queued`, number))
+	switch {
+	case err != nil:
+		if ctx.Err() == nil {
+			i.log.Warn("Cannot queue the block for refetching", "number", number, "err", err)
+		}
+	case out == "0":
+		i.log.Debug("Block already indexed or queued, not queueing it for refetching", "number", number)
+	default:
+		i.log.Info("Queued the block for refetching", "number", number)
+	}
+}
+
+// openGaps splits the gaps found by the previous scans into the ones still
+// overlapping a current gap and the number of the filled ones.
+func openGaps(previous, current []blockGap) (open []blockGap, filled int) {
+	for _, gap := range previous {
+		i := slices.IndexFunc(current, func(other blockGap) bool {
+			return other.from <= gap.to && other.to >= gap.from
+		})
+		if i < 0 {
+			filled++
+		} else {
+			open = append(open, gap)
+		}
+	}
+	return open, filled
+}
+
+// parseRow parses a row of n integer columns of the psql unaligned output.
+func parseRow(row string, n int) ([]int64, error) {
+	fields := strings.Split(row, "|")
+	if len(fields) != n {
+		return nil, fmt.Errorf("expected %d columns, got %d", n, len(fields))
+	}
+	values := make([]int64, n)
+	for i, field := range fields {
+		value, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"path"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -24,63 +26,112 @@ type Instance struct {
 	log       log.Logger
 	workspace string
 
-	cmd *exec.Cmd
+	// set by the orchestrator when the instance is started
+	stop context.CancelFunc
+	done chan struct{}
 
-	resourceCtx    context.Context
-	resourceCancel context.CancelFunc
-	closeApp       context.CancelCauseFunc
+	// the block Blockscout indexes from, raised by the pruning of old blocks
+	firstBlock atomic.Uint64
 
-	stopped   atomic.Bool
-	stoppedCh chan struct{}
+	// reported by the orchestrator's IndexerStatus
+	mu        sync.Mutex
+	status    string
+	statusErr error
+	// why the head monitoring rejects the node's blocks, nil while they are valid
+	rejected error
 }
 
-func NewInstance(log log.Logger, closeApp context.CancelCauseFunc, config *config.BlockscoutConfig, globalWorkspace string) (*Instance, error) {
+func NewInstance(log log.Logger, config *config.BlockscoutConfig, globalWorkspace string) (*Instance, error) {
 	workspace, err := createInstanceWorkspace(globalWorkspace, config.GenesisJSON)
 	if err != nil {
 		return nil, err
 	}
-	resCtx, resCancel := context.WithCancel(context.Background())
-	return &Instance{
-		config:         config,
-		log:            log,
-		workspace:      workspace,
-		resourceCtx:    resCtx,
-		resourceCancel: resCancel,
-		closeApp:       closeApp,
-		stoppedCh:      make(chan struct{}, 1),
-	}, nil
+	instance := &Instance{
+		config:    config,
+		log:       config.Logger(log),
+		workspace: workspace,
+		status:    IndexerStopped,
+	}
+	instance.firstBlock.Store(config.FirstBlock)
+	return instance, nil
 }
 
-func (i *Instance) Start(ctx context.Context) error {
-	i.log.Info("Starting Blockscout", "chain", i.config.Name)
+func (i *Instance) setStatus(status string, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.status, i.statusErr = status, err
+}
 
-	err := i.configureBlockscout()
-	if err != nil {
-		return err
+func (i *Instance) setRejected(err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rejected = err
+}
+
+// indexerStatus reports a running instance in the error state while the node
+// serves blocks failing the verification, as Blockscout still indexes them.
+func (i *Instance) indexerStatus() (string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.status == IndexerRunning && i.rejected != nil {
+		return IndexerError, fmt.Errorf("the node serves unverified blocks: %w", i.rejected)
+	}
+	return i.status, i.statusErr
+}
+
+// run starts Blockscout and blocks until docker compose exits or ctx is cancelled.
+// started reports whether docker compose was started at all, errors returned
+// before that are not worth retrying.
+func (i *Instance) run(ctx context.Context) (started bool, err error) {
+	i.log.Info("Starting Blockscout")
+
+	if err := i.configureBlockscout(); err != nil {
+		return false, err
+	}
+
+	cmd := exec.CommandContext(ctx, "docker", "compose", "up")
+	cmd.Env = append(os.Environ(), i.config.DockerComposeEnvs()...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
 	}
+	cmd.Dir = i.workspace
 
-	err = i.runDockerCompose(ctx)
+	// appended to, so that the output of crashed runs is kept
+	logFile, err := os.OpenFile(path.Join(i.workspace, "logs"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
-		return err
+		return false, err
 	}
+	defer logFile.Close()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
 
-	go i.verifyL2InteropContracts()
+	if err := cmd.Start(); err != nil {
+		return false, err
+	}
 
-	return nil
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		i.log.Info("Blockscout terminated")
+		return true, nil
+	}
+	if err == nil {
+		err = errors.New("docker compose exited unexpectedly")
+	}
+	return true, err
 }
 
-func (i *Instance) Stop(_ context.Context) error {
-	i.log.Info("Stopping Blockscout", "chain", i.config.Name)
-	if i.stopped.Load() {
-		return errors.New("already stopped")
-	}
-	if !i.stopped.CompareAndSwap(false, true) {
-		return nil // someone else stopped
+// kill stops the containers right away, when they did not stop in time.
+func (i *Instance) kill(ctx context.Context) {
+	if _, err := i.docker(ctx, "compose", "kill"); err != nil {
+		i.log.Error("Failed to kill Blockscout", "err", err)
 	}
+}
 
-	i.resourceCancel()
-	<-i.stoppedCh
-	return nil
+func (i *Instance) cleanup() {
+	i.log.Info("Stopping Blockscout")
+	if err := cleanupInstanceWorkspace(i.workspace); err != nil {
+		i.log.Error("Failed to cleanup workspace", "error", err)
+	}
 }
 
 func (i *Instance) ConfigAsString() string {
@@ -89,12 +140,34 @@ func (i *Instance) ConfigAsString() string {
 	fmt.Fprintf(&b, "* Chain: %v\n", i.config.Name)
 	fmt.Fprintf(&b, "         Frontend:  http://127.0.0.1:%v\n", i.config.FrontendPort)
 	fmt.Fprintf(&b, "         Backend:   http://127.0.0.1:%v\n", i.config.BackendPort)
-	fmt.Fprintf(&b, "         DB:        http://127.0.0.1:%v\n", i.config.PostgresPort)
+	if i.config.StorageDSN != "" {
+		fmt.Fprintf(&b, "         DB:        %v\n", utils.RedactURL(i.config.StorageDSN))
+	} else {
+		fmt.Fprintf(&b, "         DB:        http://127.0.0.1:%v\n", i.config.PostgresPort)
+	}
 	fmt.Fprintf(&b, "         Workspace: %v\n", i.workspace)
 	fmt.Fprintf(&b, "         Logs:	    %v\n", path.Join(i.workspace, "logs"))
 	fmt.Fprintf(&b, "         First block: %v\n", i.config.FirstBlock)
-	fmt.Fprintf(&b, "         RPC: %v\n", i.config.RPCUrl)
+	fmt.Fprintf(&b, "         RPC: %v\n", utils.RedactURL(i.config.RPCUrl))
+	if fallbacks := i.config.FallbackRPCUrls(); len(fallbacks) > 0 {
+		redacted := make([]string, len(fallbacks))
+		for j, fallback := range fallbacks {
+			redacted[j] = utils.RedactURL(fallback)
+		}
+		fmt.Fprintf(&b, "         Fallback RPCs: %v\n", strings.Join(redacted, ", "))
+	}
+	if i.config.WSUrl != "" {
+		fmt.Fprintf(&b, "         WS RPC: %v\n", utils.RedactURL(i.config.WSUrl))
+	} else {
+		fmt.Fprintf(&b, "         Poll interval: %v\n", i.config.PollIntervalOrDefault())
+	}
+	if len(i.config.RPCHeaders) > 0 {
+		// the values are usually credentials
+		names := slices.Sorted(maps.Keys(i.config.RPCHeaders))
+		fmt.Fprintf(&b, "         RPC headers: %v\n", strings.Join(names, ", "))
+	}
 	fmt.Fprintf(&b, "         Chain ID: %v\n", i.config.ChainID)
+	fmt.Fprintf(&b, "         Traces: %v\n", i.config.EnableTraces)
 
 	if i.config.OPConfig != nil {
 		fmt.Fprintf(&b, "         Optimism L1 RPC: %v\n", i.config.OPConfig.L1RPCUrl)
@@ -104,77 +177,14 @@ func (i *Instance) ConfigAsString() string {
 }
 
 func (i *Instance) configureBlockscout() error {
-	utils.PatchDotEnv(path.Join(i.workspace, "common-blockscout.env"), i.config.BackendEnvs())
+	envs := i.config.BackendEnvs()
+	envs["FIRST_BLOCK"] = fmt.Sprintf("%d", i.firstBlock.Load())
+	utils.PatchDotEnv(path.Join(i.workspace, "common-blockscout.env"), envs)
 	utils.PatchDotEnv(path.Join(i.workspace, "common-frontend.env"), i.config.FrontendEnvs())
 	return nil
 }
 
-func (i *Instance) runDockerCompose(ctx context.Context) error {
-	i.cmd = exec.CommandContext(i.resourceCtx, "docker", "compose", "up")
-	i.cmd.Env = append(os.Environ(), i.config.DockerComposeEnvs()...)
-	i.cmd.Cancel = func() error {
-		return i.cmd.Process.Signal(syscall.SIGTERM)
-	}
-	i.cmd.Dir = i.workspace
-	go func() {
-		<-ctx.Done()
-		i.resourceCancel()
-	}()
-
-	stdout, err := i.cmd.StdoutPipe()
-	if err != nil {
-		return err
-	}
-
-	logFile, err := os.Create(path.Join(i.workspace, "logs"))
-	if err != nil {
-		return err
-	}
-
-	go func() {
-		if _, err := io.Copy(logFile, stdout); err != nil {
-			i.log.Warn("err piping stderr to log file", "err", err)
-		}
-	}()
-
-	stderr, err := i.cmd.StderrPipe()
-	if err != nil {
-		return err
-	}
-
-	go func() {
-		if _, err := io.Copy(logFile, stderr); err != nil {
-			i.log.Warn("err piping stderr to log file", "err", err)
-		}
-	}()
-
-	if err := i.cmd.Start(); err != nil {
-		return err
-	}
-
-	go func() {
-		if err := i.cmd.Wait(); err != nil {
-			if err.Error() != "exit status 130" {
-				i.log.Error("Blockscout terminated with an error", "error", err)
-			}
-		} else {
-			i.log.Info("Blockscout terminated")
-		}
-
-		err := cleanupInstanceWorkspace(i.workspace)
-		if err != nil {
-			i.log.Error("Failed to cleanup workspace", "error", err)
-		}
-
-		// If it stops, signal that the entire app should be closed
-		i.closeApp(nil)
-		i.stoppedCh <- struct{}{}
-	}()
-
-	return nil
-}
-
-func (i *Instance) verifyL2InteropContracts() {
+func (i *Instance) verifyL2InteropContracts(ctx context.Context) {
 	if i.config.OPConfig == nil {
 		// the instance corresponds to L1 chain
 		return
@@ -187,14 +197,18 @@ func (i *Instance) verifyL2InteropContracts() {
 		"SuperchainTokenBridge":      common.HexToAddress("0x4200000000000000000000000000000000000028"),
 	}
 
-	backendURL := fmt.Sprintf("http://127.0.0.1:%d", i.config.BackendPort)
+	backendURL := i.config.BackendURL()
 
 	// Wait for the backend instance to start
 	for !isHealthy(backendURL) {
-		time.Sleep(1 * time.Second)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(1 * time.Second):
+		}
 	}
 
-	i.log.Info("Verifying predeployed interop contracts", "chain", i.config.Name)
+	i.log.Info("Verifying predeployed interop contracts")
 
 	interopImplementations := map[string][]common.Address{}
 	for name, proxy := range interopProxies {
@@ -202,7 +216,6 @@ func (i *Instance) verifyL2InteropContracts() {
 		if err != nil {
 			i.log.Error(
 				"Failed to retrieve proxy implementation address",
-				"chain", i.config.Name,
 				"name", name,
 				"err", err,
 			)
@@ -218,7 +231,6 @@ func (i *Instance) verifyL2InteropContracts() {
 			if err != nil {
 				i.log.Error(
 					"Failed to verify interop contract",
-					"chain", i.config.Name,
 					"name", name,
 					"address", implementation,
 					"err", err,
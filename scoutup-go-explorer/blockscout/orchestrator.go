@@ -3,19 +3,52 @@ package blockscout
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/blockscout/scoutup/config"
+	"github.com/blockscout/scoutup/monitor"
 	"github.com/ethereum/go-ethereum/log"
 )
 
+const (
+	maxInstanceRestarts = 5
+	// an instance running for longer than that is considered recovered
+	stableInstanceRun   = 5 * time.Minute
+	initialRestartDelay = 1 * time.Second
+	maxRestartDelay     = 30 * time.Second
+	// how long the killed instances get to exit
+	killTimeout = 30 * time.Second
+)
+
+// The states of an indexer reported by IndexerStatus.
+const (
+	IndexerRunning = "running"
+	IndexerStopped = "stopped"
+	// the indexer crashed and is waiting to be restarted, or gave up
+	IndexerError = "error"
+)
+
 type Orchestrator struct {
-	instances []*Instance
+	log             log.Logger
+	onReorg         monitor.ReorgHandler
+	globalWorkspace string
 
-	log log.Logger
+	// instances are added and removed on config reloads
+	mu        sync.Mutex
+	instances []*Instance
+	// the supervisors run until ctx is cancelled, set by Start
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
-func NewOrchestrator(log log.Logger, closeApp context.CancelCauseFunc, configs []*config.BlockscoutConfig) (*Orchestrator, error) {
+// NewOrchestrator prepares the instances of the configs, onReorg, when set,
+// is called on every reorg detected while they run.
+func NewOrchestrator(log log.Logger, configs []*config.BlockscoutConfig, onReorg monitor.ReorgHandler) (*Orchestrator, error) {
 	globalWorkspace, err := createGlobalWorkspace()
 	if err != nil {
 		return nil, err
@@ -23,38 +56,282 @@ func NewOrchestrator(log log.Logger, closeApp context.CancelCauseFunc, configs [
 
 	instances := []*Instance{}
 	for _, config := range configs {
-		instance, err := NewInstance(log, closeApp, config, globalWorkspace)
+		instance, err := NewInstance(log, config, globalWorkspace)
 		if err != nil {
 			return nil, err
 		}
 		instances = append(instances, instance)
 	}
-	return &Orchestrator{instances: instances, log: log}, nil
+	return &Orchestrator{
+		instances:       instances,
+		log:             log,
+		onReorg:         onReorg,
+		globalWorkspace: globalWorkspace,
+	}, nil
 }
 
+// Start runs a supervisor for every instance. The instances are started
+// concurrently and restarted when they crash. An instance that cannot be
+// started or keeps crashing is left in the error state while the others
+// keep running.
 func (o *Orchestrator) Start(ctx context.Context) error {
+	o.mu.Lock()
+	o.ctx, o.cancel = context.WithCancel(ctx)
 	for _, instance := range o.instances {
-		if err := instance.Start(ctx); err != nil {
-			o.log.Error("Failed to start Blockscout instance", "err", err)
+		o.start(instance)
+	}
+	o.mu.Unlock()
+
+	o.log.Info(o.ConfigAsString())
+	return nil
+}
+
+func (o *Orchestrator) start(instance *Instance) {
+	ctx, cancel := context.WithCancel(o.ctx)
+	instance.stop = cancel
+	instance.done = make(chan struct{})
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		defer close(instance.done)
+		if err := o.supervise(ctx, instance); err != nil {
+			instance.setStatus(IndexerError, err)
+			instance.log.Error("Blockscout instance failed, the other chains keep running", "err", err)
+			return
+		}
+		instance.setStatus(IndexerStopped, nil)
+	}()
+}
+
+// AddInstances starts instances for the configs while the others keep running,
+// e.g. for the chains added by a config reload.
+func (o *Orchestrator) AddInstances(configs []*config.BlockscoutConfig) error {
+	instances := make([]*Instance, 0, len(configs))
+	for _, config := range configs {
+		instance, err := NewInstance(o.log, config, o.globalWorkspace)
+		if err != nil {
 			return err
 		}
+		instances = append(instances, instance)
 	}
 
-	o.log.Info(o.ConfigAsString())
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, instance := range instances {
+		o.instances = append(o.instances, instance)
+		o.start(instance)
+		o.log.Info("Added Blockscout instance\n" + instance.ConfigAsString())
+	}
 	return nil
 }
 
+// RemoveInstance stops the instance of the chain while the others keep running,
+// it is killed when it does not stop within the shutdown timeout.
+func (o *Orchestrator) RemoveInstance(ctx context.Context, chainID uint64) error {
+	o.mu.Lock()
+	idx := slices.IndexFunc(o.instances, func(instance *Instance) bool { return instance.config.ChainID == chainID })
+	if idx < 0 {
+		o.mu.Unlock()
+		return fmt.Errorf("no instance of chain %d is running", chainID)
+	}
+	instance := o.instances[idx]
+	o.instances = slices.Delete(o.instances, idx, idx+1)
+	o.mu.Unlock()
+
+	instance.stop()
+	timeout := instance.config.ShutdownTimeout
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	select {
+	case <-instance.done:
+		return nil
+	case <-waitCtx.Done():
+	}
+
+	instance.log.Error("Blockscout instance did not stop in time, killing it", "timeout", timeout)
+	killCtx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+	instance.kill(killCtx)
+	select {
+	case <-instance.done:
+	case <-killCtx.Done():
+	}
+	return fmt.Errorf("blockscout instance of %s did not stop within %s", instance.config.Name, timeout)
+}
+
+// Stop asks the instances to stop and waits for them. Blockscout commits every
+// imported batch of blocks in a single database transaction, so a graceful
+// stop leaves only fully indexed blocks behind. The instances still running
+// after the shutdown timeout, or once ctx is cancelled, are killed.
 func (o *Orchestrator) Stop(ctx context.Context) error {
-	for _, instance := range o.instances {
-		instance.Stop(ctx)
+	o.mu.Lock()
+	if o.cancel != nil {
+		o.cancel()
 	}
-	return nil
+	instances := slices.Clone(o.instances)
+	o.mu.Unlock()
+
+	timeout := shutdownTimeout(instances)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if o.wait(ctx) {
+		return nil
+	}
+
+	o.log.Error("Blockscout instances did not stop in time, killing them", "timeout", timeout)
+	killCtx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+	for _, instance := range instances {
+		instance.kill(killCtx)
+	}
+	o.wait(killCtx)
+	return fmt.Errorf("blockscout instances did not stop within %s", timeout)
+}
+
+// wait reports whether all the instances stopped before ctx is done.
+func (o *Orchestrator) wait(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func shutdownTimeout(instances []*Instance) time.Duration {
+	timeout := time.Duration(0)
+	for _, instance := range instances {
+		timeout = max(timeout, instance.config.ShutdownTimeout)
+	}
+	return timeout
+}
+
+func (o *Orchestrator) supervise(ctx context.Context, instance *Instance) error {
+	defer instance.cleanup()
+	if err := instance.prepareDatabase(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	go runTask(ctx, instance, "interop contracts check", instance.verifyL2InteropContracts)
+	go runTask(ctx, instance, "gap reconciler", instance.reconcileGaps)
+	go runTask(ctx, instance, "pruner", instance.pruneIndexedData)
+	handlers := monitor.Handlers{Reorg: o.onReorg, Reject: instance.setRejected}
+	if instance.config.StorageDSN == "" {
+		// the bundled database is the only one reachable through docker
+		handlers.Rewind, handlers.Skip = instance.rewindOrphaned, instance.queueBlock
+	}
+	go runTask(ctx, instance, "head monitor", func(ctx context.Context) {
+		// a new monitor verifies the blocks from scratch
+		instance.setRejected(nil)
+		monitor.New(o.log, instance.config, handlers).Run(ctx)
+	})
+
+	restarts := 0
+	delay := initialRestartDelay
+	for {
+		startedAt := time.Now()
+		instance.setStatus(IndexerRunning, nil)
+		started, err := o.runInstance(ctx, instance)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !started {
+			return fmt.Errorf("cannot start Blockscout: %w", err)
+		}
+
+		if time.Since(startedAt) > stableInstanceRun {
+			restarts = 0
+			delay = initialRestartDelay
+		}
+		if restarts >= maxInstanceRestarts {
+			return fmt.Errorf("giving up after %d restarts: %w", restarts, err)
+		}
+		restarts++
+		instance.setStatus(IndexerError, err)
+
+		instance.log.Error("Blockscout crashed, restarting", "restart", restarts, "delay", delay, "err", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRestartDelay)
+	}
+}
+
+// runTask runs a background task of the instance until it returns, restarting
+// it after a panic so that it cannot take down the other chains. It is given
+// up on after as many panics as the instance gets restarts.
+func runTask(ctx context.Context, instance *Instance, name string, task func(ctx context.Context)) {
+	delay := initialRestartDelay
+	for restarts := 0; ; restarts++ {
+		if !runTaskOnce(ctx, instance, name, task) || ctx.Err() != nil {
+			return
+		}
+		if restarts >= maxInstanceRestarts {
+			instance.log.Error("Giving up on the background task", "task", name, "restarts", restarts)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRestartDelay)
+	}
+}
+
+// runTaskOnce reports whether the task panicked.
+func runTaskOnce(ctx context.Context, instance *Instance, name string, task func(ctx context.Context)) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			instance.log.Error("Background task panicked", "task", name, "panic", r, "stack", string(debug.Stack()))
+			panicked = true
+		}
+	}()
+	task(ctx)
+	return false
+}
+
+// runInstance runs the instance, recovering from panics so that a single
+// chain cannot take down the whole process.
+func (o *Orchestrator) runInstance(ctx context.Context, instance *Instance) (started bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			instance.log.Error("Blockscout instance panicked", "panic", r, "stack", string(debug.Stack()))
+			started, err = true, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return instance.run(ctx)
+}
+
+// IndexerStatus returns the state of the chain's indexer and, in the error
+// state, the error it crashed with. Chains without an instance are stopped.
+func (o *Orchestrator) IndexerStatus(chainID uint64) (string, error) {
+	o.mu.Lock()
+	idx := slices.IndexFunc(o.instances, func(instance *Instance) bool { return instance.config.ChainID == chainID })
+	if idx < 0 {
+		o.mu.Unlock()
+		return IndexerStopped, nil
+	}
+	instance := o.instances[idx]
+	o.mu.Unlock()
+	return instance.indexerStatus()
 }
 
 func (o *Orchestrator) ConfigAsString() string {
 	var b strings.Builder
 	fmt.Fprintln(&b, "\nBlockscout Config:")
 	fmt.Fprintln(&b, "------------------")
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	for _, instance := range o.instances {
 		fmt.Fprintln(&b, instance.ConfigAsString())
 	}
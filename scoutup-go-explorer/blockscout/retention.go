@@ -0,0 +1,156 @@
+package blockscout
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// blocks pruned per database transaction, keeping the locks short
+const pruneBatchBlocks = 1000
+
+// prunedTables are the tables of the indexed data deleted along with their
+// blocks, the tables referencing the others first.
+var prunedTables = []struct {
+	name  string
+	where string
+}{
+	{"logs", "block_number < %[1]d"},
+	{"token_transfers", "block_number < %[1]d"},
+	{"internal_transactions", "block_number < %[1]d"},
+	{"transactions", "block_number < %[1]d"},
+	{"withdrawals", "block_hash IN (SELECT hash FROM blocks WHERE number < %[1]d)"},
+	{"block_rewards", "block_hash IN (SELECT hash FROM blocks WHERE number < %[1]d)"},
+	{"pending_block_operations", "block_hash IN (SELECT hash FROM blocks WHERE number < %[1]d)"},
+	{"blocks", "number < %[1]d"},
+}
+
+// retentionFloor raises the first block Blockscout indexes to the oldest
+// retained one, so that the pruned blocks are not fetched again.
+func (i *Instance) retentionFloor(tip uint64) uint64 {
+	keep := i.config.RetainedBlocks()
+	if keep == 0 || tip+1 <= keep {
+		return i.firstBlock.Load()
+	}
+	floor := max(tip+1-keep, i.config.FirstBlock)
+	if floor > i.firstBlock.Load() {
+		i.firstBlock.Store(floor)
+	}
+	return i.firstBlock.Load()
+}
+
+// pruneIndexedData periodically deletes the blocks older than the retained
+// ones along with their transactions and logs, until ctx is cancelled.
+func (i *Instance) pruneIndexedData(ctx context.Context) {
+	// retentionBlocks is rejected with an external database by Validate
+	if i.config.RetainedBlocks() == 0 {
+		return
+	}
+	interval := i.config.PruneIntervalOrDefault()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		if err := i.prune(ctx); err != nil && ctx.Err() == nil {
+			i.log.Warn("Cannot prune the indexed data", "err", err)
+		}
+	}
+}
+
+func (i *Instance) prune(ctx context.Context) error {
+	out, err := i.psql(ctx, "SELECT tablename FROM pg_tables WHERE schemaname = 'public'")
+	if err != nil {
+		return err
+	}
+	existing := strings.Split(out, "\n")
+	if !slices.Contains(existing, "blocks") {
+		// nothing indexed yet
+		return nil
+	}
+	var tables []string
+	var statements []string
+	for _, table := range prunedTables {
+		if slices.Contains(existing, table.name) {
+			tables = append(tables, table.name)
+			statements = append(statements, fmt.Sprintf("WITH pruned AS (DELETE FROM %s WHERE %s RETURNING 1) SELECT COUNT(*) FROM pruned", table.name, table.where))
+		}
+	}
+	if slices.Contains(existing, "missing_block_ranges") {
+		// the ranges go from the higher block down to the lower one
+		statements = append(statements,
+			"DELETE FROM missing_block_ranges WHERE from_number < %[1]d",
+			"UPDATE missing_block_ranges SET to_number = %[1]d WHERE to_number < %[1]d")
+	}
+
+	summary, err := i.psql(ctx, "SELECT COALESCE(MIN(number), -1), COALESCE(MAX(number) FILTER (WHERE consensus), -1) FROM blocks")
+	if err != nil {
+		return err
+	}
+	bounds, err := parseRow(summary, 2)
+	if err != nil {
+		return fmt.Errorf("unexpected indexed range %q: %w", summary, err)
+	}
+	lowest, tip := bounds[0], bounds[1]
+	if tip < 0 {
+		return nil
+	}
+	cutoff := i.retentionFloor(uint64(tip))
+	if lowest < 0 || uint64(lowest) >= cutoff {
+		return nil
+	}
+
+	start := time.Now()
+	pruned := make(map[string]int64, len(tables))
+	for below := uint64(lowest) + pruneBatchBlocks; ; below += pruneBatchBlocks {
+		below = min(below, cutoff)
+		counts, err := i.pruneBelow(ctx, statements, below)
+		if err != nil {
+			return fmt.Errorf("cannot prune the blocks below %d: %w", below, err)
+		}
+		if len(counts) != len(tables) {
+			return fmt.Errorf("unexpected pruned counts %v", counts)
+		}
+		for n, table := range tables {
+			pruned[table] += counts[n]
+		}
+		if below == cutoff {
+			break
+		}
+	}
+
+	other := int64(0)
+	for table, count := range pruned {
+		if table != "blocks" && table != "transactions" && table != "logs" {
+			other += count
+		}
+	}
+	i.log.Info("Pruned indexed data", "below", cutoff, "blocks", pruned["blocks"], "transactions", pruned["transactions"],
+		"logs", pruned["logs"], "other", other, "duration", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// pruneBelow runs the statements in a single transaction and returns the
+// counts of the deleted rows.
+func (i *Instance) pruneBelow(ctx context.Context, statements []string, below uint64) ([]int64, error) {
+	args := []string{"compose", "exec", "-T", "db", "psql", "-U", "blockscout", "-d", "blockscout", "-tA", "--single-transaction", "-v", "ON_ERROR_STOP=1"}
+	for _, statement := range statements {
+		args = append(args, "-c", fmt.Sprintf(statement, below))
+	}
+	out, err := i.docker(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	// the statements without a count only print their command tag
+	var counts []int64
+	for _, line := range strings.Split(out, "\n") {
+		if count, err := strconv.ParseInt(line, 10, 64); err == nil {
+			counts = append(counts, count)
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,279 @@
+package blockscout
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blockscout/scoutup/config"
+	"github.com/blockscout/scoutup/utils"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// A snapshot is a tar archive of its metadata followed by a pg_dump of the
+// Blockscout database in the custom format, which is compressed already.
+const (
+	snapshotVersion  = 1
+	snapshotMetadata = "metadata.json"
+	snapshotDump     = "blockscout.dump"
+)
+
+type SnapshotMetadata struct {
+	Version   int    `json:"version"`
+	ChainID   uint64 `json:"chainId"`
+	Chain     string `json:"chain"`
+	DockerTag string `json:"dockerTag"`
+	// the latest consensus block of the snapshot, indexing resumes from there
+	IndexedTip uint64    `json:"indexedTip"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ExportSnapshot writes a snapshot of the chain's bundled database to file.
+// The database is dumped from the running instance when there is one,
+// otherwise it is started on its own for the time of the export.
+func ExportSnapshot(ctx context.Context, log log.Logger, cfg *config.BlockscoutConfig, file string) (*SnapshotMetadata, error) {
+	db, err := openSnapshotDatabase(ctx, log, cfg, true)
+	if err != nil {
+		return nil, err
+	}
+	defer db.close()
+
+	tip, err := db.indexedTip(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tip < 0 {
+		return nil, errors.New("nothing is indexed yet")
+	}
+	metadata := &SnapshotMetadata{
+		Version:    snapshotVersion,
+		ChainID:    cfg.ChainID,
+		Chain:      cfg.Name,
+		DockerTag:  cfg.DockerTag,
+		IndexedTip: uint64(tip),
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	// the size of a tar entry goes before its content
+	dump, err := os.CreateTemp("", "scoutup-snapshot-*.dump")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(dump.Name())
+	defer dump.Close()
+	db.log.Info("Dumping the database", "indexedTip", tip)
+	if err := db.exec(ctx, nil, dump, "pg_dump", "-U", "blockscout", "-d", "blockscout", "-Fc"); err != nil {
+		return nil, fmt.Errorf("cannot dump the database: %w", err)
+	}
+
+	if err := writeSnapshot(file, metadata, dump); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func writeSnapshot(file string, metadata *SnapshotMetadata, dump *os.File) error {
+	info, err := dump.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := dump.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	metadataJSON, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// written next to the file first, so that a failed export does not leave
+	// a truncated snapshot behind
+	tmp := file + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer out.Close()
+
+	archive := tar.NewWriter(out)
+	if err := archive.WriteHeader(&tar.Header{Name: snapshotMetadata, Mode: 0644, Size: int64(len(metadataJSON)), ModTime: metadata.CreatedAt}); err != nil {
+		return err
+	}
+	if _, err := archive.Write(metadataJSON); err != nil {
+		return err
+	}
+	if err := archive.WriteHeader(&tar.Header{Name: snapshotDump, Mode: 0644, Size: info.Size(), ModTime: metadata.CreatedAt}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(archive, dump); err != nil {
+		return fmt.Errorf("cannot write the snapshot: %w", err)
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// ImportSnapshot loads the snapshot into the chain's bundled database, which
+// has to be empty and not in use. A snapshot of another chain id is refused.
+func ImportSnapshot(ctx context.Context, log log.Logger, cfg *config.BlockscoutConfig, file string) (*SnapshotMetadata, error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	archive := tar.NewReader(in)
+
+	var metadata SnapshotMetadata
+	if err := nextSnapshotEntry(archive, snapshotMetadata); err != nil {
+		return nil, err
+	}
+	if err := json.NewDecoder(archive).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("invalid snapshot metadata: %w", err)
+	}
+	switch {
+	case metadata.Version != snapshotVersion:
+		return nil, fmt.Errorf("unsupported snapshot version %d", metadata.Version)
+	case metadata.ChainID != cfg.ChainID:
+		return nil, fmt.Errorf("the snapshot of chain %d (%s) cannot be imported into chain %d (%s)", metadata.ChainID, metadata.Chain, cfg.ChainID, cfg.Name)
+	}
+	if metadata.DockerTag != cfg.DockerTag {
+		cfg.Logger(log).Warn("The snapshot was made with another Blockscout version, its schema is migrated on start", "snapshot", metadata.DockerTag, "current", cfg.DockerTag)
+	}
+
+	db, err := openSnapshotDatabase(ctx, log, cfg, false)
+	if err != nil {
+		return nil, err
+	}
+	defer db.close()
+	tip, err := db.indexedTip(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tip != -2 {
+		return nil, fmt.Errorf("the database of %s is not empty, remove it with docker volume rm %s before importing", cfg.Name, cfg.DatabaseVolume())
+	}
+
+	if err := nextSnapshotEntry(archive, snapshotDump); err != nil {
+		return nil, err
+	}
+	db.log.Info("Restoring the database", "indexedTip", metadata.IndexedTip)
+	if err := db.exec(ctx, archive, io.Discard, "pg_restore", "-U", "blockscout", "-d", "blockscout", "--no-owner", "--exit-on-error"); err != nil {
+		return nil, fmt.Errorf("cannot restore the database: %w", err)
+	}
+	return &metadata, nil
+}
+
+func nextSnapshotEntry(archive *tar.Reader, name string) error {
+	header, err := archive.Next()
+	if err != nil {
+		return fmt.Errorf("not a scoutup snapshot: %w", err)
+	}
+	if header.Name != name {
+		return fmt.Errorf("not a scoutup snapshot: expected %s, got %s", name, header.Name)
+	}
+	return nil
+}
+
+// snapshotDatabase is the bundled database of a chain, either the one of the
+// running instance or one started for the snapshot.
+type snapshotDatabase struct {
+	*Instance
+	// the db container of the running instance, empty when started here
+	container string
+}
+
+// openSnapshotDatabase makes the chain's database available. Exports use the
+// database of the running instance, imports refuse it as it is in use.
+func openSnapshotDatabase(ctx context.Context, log log.Logger, cfg *config.BlockscoutConfig, export bool) (*snapshotDatabase, error) {
+	if cfg.StorageDSN != "" {
+		return nil, errors.New("snapshots cover the bundled database only, use pg_dump and pg_restore with storageDsn")
+	}
+	globalWorkspace, err := createGlobalWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	instance, err := NewInstance(log, cfg, globalWorkspace)
+	if err != nil {
+		return nil, err
+	}
+	db := &snapshotDatabase{Instance: instance}
+
+	container := utils.NameToContainerName("db", cfg.Name)
+	running, err := db.docker(ctx, "ps", "--quiet", "--filter", "name=^"+container+"$")
+	if err != nil {
+		db.close()
+		return nil, err
+	}
+	switch {
+	case running != "" && export:
+		db.container = container
+		return db, nil
+	case running != "":
+		db.close()
+		return nil, fmt.Errorf("the database of %s is in use, stop scoutup before importing", cfg.Name)
+	}
+
+	if export {
+		if _, err := db.docker(ctx, "volume", "inspect", cfg.DatabaseVolume()); err != nil {
+			db.close()
+			return nil, fmt.Errorf("%s has no indexed data: %w", cfg.Name, err)
+		}
+	} else if _, err := db.docker(ctx, "volume", "create", cfg.DatabaseVolume()); err != nil {
+		db.close()
+		return nil, fmt.Errorf("cannot create database volume: %w", err)
+	}
+	if _, err := db.docker(ctx, "compose", "up", "--detach", "--wait", "db"); err != nil {
+		db.close()
+		return nil, fmt.Errorf("cannot start the database: %w", err)
+	}
+	return db, nil
+}
+
+// close stops the database if it was started for the snapshot, its data is kept.
+func (db *snapshotDatabase) close() {
+	if err := cleanupInstanceWorkspace(db.workspace); err != nil {
+		db.log.Error("Failed to cleanup workspace", "error", err)
+	}
+}
+
+// exec runs the command in the database container.
+func (db *snapshotDatabase) exec(ctx context.Context, stdin io.Reader, stdout io.Writer, args ...string) error {
+	prefix := []string{"compose", "exec", "-T", "db"}
+	if db.container != "" {
+		prefix = []string{"exec", "-i", db.container}
+	}
+	return db.dockerIO(ctx, stdin, stdout, append(prefix, args...)...)
+}
+
+// indexedTip returns the latest consensus block, -1 when none is indexed and
+// -2 when the database has no schema yet.
+func (db *snapshotDatabase) indexedTip(ctx context.Context) (int64, error) {
+	query := func(q string) (string, error) {
+		var out strings.Builder
+		err := db.exec(ctx, nil, &out, "psql", "-U", "blockscout", "-d", "blockscout", "-tAc", q)
+		return strings.TrimSpace(out.String()), err
+	}
+	exists, err := query("SELECT to_regclass('public.blocks') IS NOT NULL")
+	if err != nil {
+		return 0, err
+	}
+	if exists != "t" {
+		return -2, nil
+	}
+	tip, err := query("SELECT COALESCE(MAX(number), -1) FROM blocks WHERE consensus")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(tip, 10, 64)
+}
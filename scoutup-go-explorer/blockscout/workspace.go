@@ -79,6 +79,9 @@ func createInstanceWorkspace(globalWorkspace string, genesisJSON []byte) (string
 		"common-blockscout.env": commonBlockscoutEnv,
 		"common-frontend.env":   commonFrontendEnv,
 		"genesis.json":          genesisJSON,
+		// created up front, so that cleanup recognizes the workspace
+		// even when Blockscout never started
+		"logs": nil,
 	}
 
 	for name, content := range files {
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// loadABIs parses the ABI files of the chain into ContractABIs, relative
+// paths are resolved against dir.
+func (n *ChainConfig) loadABIs(dir string) error {
+	if len(n.ABIs) == 0 {
+		return nil
+	}
+
+	var errs []error
+	n.ContractABIs = make(map[common.Address]*abi.ABI, len(n.ABIs))
+	for address, path := range n.ABIs {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		parsed, err := parseABIFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("abis[%s]: %w", address, err))
+			continue
+		}
+		n.ContractABIs[common.HexToAddress(address)] = parsed
+	}
+	return errors.Join(errs...)
+}
+
+// parseABIFile accepts a plain ABI as well as a compiler artifact holding it
+// under "abi", as written by Foundry and Hardhat.
+func parseABIFile(path string) (*abi.ABI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if json.Unmarshal(data, &artifact) == nil && len(artifact.ABI) > 0 {
+		data = artifact.ABI
+	}
+	parsed, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	return &parsed, nil
+}
@@ -8,6 +8,8 @@ func PrepareDefaultAnvilConfig() *NetworkConfig {
 				RPCUrl:     "http://host.docker.internal:8545",
 				FirstBlock: 0,
 				ChainID:    9323310,
+				// the local node serves the debug namespace
+				EnableTraces: true,
 			},
 		},
 	}
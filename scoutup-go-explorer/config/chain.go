@@ -1,18 +1,247 @@
 package config
 
+import (
+	"math"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultConcurrency = 1
+	defaultMaxRetries  = 3
+	defaultReorgDepth  = 64
+	defaultMempoolTTL  = 10 * time.Minute
+	// Blockscout's own default of the catchup interval
+	defaultPollInterval = 5 * time.Second
+	// keeps the logs queries served by Blockscout fast
+	defaultMaxLogsBlockRange = 10000
+	// only pathological responses are that large
+	defaultMaxResponseSize = 128 << 20
+	defaultPruneInterval   = time.Hour
+)
+
 type OPConfig struct {
-	L1RPCUrl               string
-	L1SystemConfigContract string
-	L1BlockscoutURL        string
+	L1RPCUrl               string `yaml:"l1RpcUrl" json:"l1RpcUrl"`
+	L1SystemConfigContract string `yaml:"l1SystemConfigContract" json:"l1SystemConfigContract"`
+	L1BlockscoutURL        string `yaml:"l1BlockscoutUrl" json:"l1BlockscoutUrl"`
 }
 
 type ChainConfig struct {
-	Name        string
-	RPCUrl      string
-	FirstBlock  uint64
-	ChainID     uint64
-	GenesisJSON []byte
-	OPConfig    *OPConfig
+	Name        string    `yaml:"name" json:"name"`
+	RPCUrl      string    `yaml:"rpcUrl" json:"rpcUrl"`
+	FirstBlock  uint64    `yaml:"firstBlock" json:"firstBlock"`
+	ChainID     uint64    `yaml:"chainId" json:"chainId"`
+	GenesisJSON []byte    `yaml:"-" json:"-"`
+	OPConfig    *OPConfig `yaml:"opConfig" json:"opConfig"`
+
+	// Hash of the block to start indexing from, resolved to FirstBlock on start
+	FirstBlockHash string `yaml:"firstBlockHash" json:"firstBlockHash"`
+	// Fallback endpoints of the same chain. RPCUrl, when set, always comes first.
+	RPCUrls []string `yaml:"rpcUrls" json:"rpcUrls"`
+	// WebSocket endpoint for newHeads subscriptions, HTTP polling is used when empty
+	WSUrl string `yaml:"wsUrl" json:"wsUrl"`
+	// How often new blocks are polled for, 5s when unset, 0 polls again as soon as
+	// a poll is done, as fast as rpcRateLimit allows
+	PollInterval *Duration `yaml:"pollInterval" json:"pollInterval"`
+	// Most blocks a logs API query may span, 10000 when unset
+	MaxLogsBlockRange uint64 `yaml:"maxLogsBlockRange" json:"maxLogsBlockRange"`
+	// Number of block batches fetched in parallel by the catchup indexer, 1 when unset
+	Concurrency int `yaml:"concurrency" json:"concurrency"`
+	// Maximum number of requests per JSON-RPC batch, e.g. receipts of a block.
+	// Blockscout's default is used when unset.
+	BatchSize int `yaml:"batchSize" json:"batchSize"`
+	// Retries of transient RPC failures made by scoutup itself, 3 when unset, negative disables retries
+	MaxRetries int `yaml:"maxRetries" json:"maxRetries"`
+	// Largest RPC response in bytes read by scoutup itself, 128MiB when unset. Blockscout
+	// reads the responses of its own requests in full
+	MaxResponseSize int64 `yaml:"maxResponseSize" json:"maxResponseSize"`
+	// How many blocks back a reorg is followed before giving up, 64 when unset
+	ReorgDepth uint64 `yaml:"reorgDepth" json:"reorgDepth"`
+	// Recompute the hash of every block followed by the head monitoring from its
+	// header fields and reject the blocks the node returned another hash for
+	VerifyBlockHashes bool `yaml:"verifyBlockHashes" json:"verifyBlockHashes"`
+	// Blocks mined on top of a block before the API reports it as finalized,
+	// used when the node does not serve the "finalized" block tag
+	Confirmations uint64 `yaml:"confirmations" json:"confirmations"`
+	// Index internal transactions traced with debug_traceBlockByNumber and the call tracer
+	EnableTraces bool `yaml:"enableTraces" json:"enableTraces"`
+	// Track the pending transactions of the node's txpool for the pending API
+	WatchMempool bool `yaml:"watchMempool" json:"watchMempool"`
+	// How long a transaction dropped from the txpool without being mined is still listed, 10m when unset
+	MempoolTTL Duration `yaml:"mempoolTtl" json:"mempoolTtl"`
+	// Most recent blocks kept of the indexed data, older ones are pruned. Everything is kept when unset
+	RetentionBlocks uint64 `yaml:"retentionBlocks" json:"retentionBlocks"`
+	// How often the blocks beyond retentionBlocks are pruned, 1h when unset
+	PruneInterval Duration `yaml:"pruneInterval" json:"pruneInterval"`
+	// PostgreSQL connection string of an external database used instead of the bundled one
+	StorageDSN string `yaml:"storageDsn" json:"storageDsn"`
+	// Extra headers sent with every RPC request, e.g. Authorization
+	RPCHeaders map[string]string `yaml:"rpcHeaders" json:"rpcHeaders"`
+	// Requests per second scoutup sends to the chain's RPC, unlimited when unset
+	RPCRateLimit float64 `yaml:"rpcRateLimit" json:"rpcRateLimit"`
+	// ERC-20 contracts served by the token API, all indexed tokens when empty
+	Tokens []string `yaml:"tokens" json:"tokens"`
+	// ABI JSON files of contracts by address, relative to the config file
+	ABIs map[string]string `yaml:"abis" json:"abis"`
+	// parsed ABIs, loaded along with the config file
+	ContractABIs map[common.Address]*abi.ABI `yaml:"-" json:"-"`
+	// Genesis JSON file whose alloc is imported as the opening balances at block 0, relative to the config file
+	GenesisAllocPath string `yaml:"genesisAllocPath" json:"genesisAllocPath"`
+	// opening balances of the genesis alloc, loaded along with the config file
+	GenesisAlloc map[common.Address]*big.Int `yaml:"-" json:"-"`
+
+	// set by RewriteDockerHosts, scoutup then dials host.docker.internal as localhost
+	localRPC bool
+	// set by Preflight, the chain id check uses its outcome instead of asking again
+	preflight *PreflightResult
+
+	limiterOnce sync.Once
+	limiter     *rate.Limiter
+}
+
+// RPCEndpoints returns all configured RPC endpoints starting with RPCUrl.
+func (n *ChainConfig) RPCEndpoints() []string {
+	if len(n.RPCUrls) == 0 {
+		if n.RPCUrl == "" {
+			return nil
+		}
+		return []string{n.RPCUrl}
+	}
+
+	endpoints := []string{}
+	if n.RPCUrl != "" {
+		endpoints = append(endpoints, n.RPCUrl)
+	}
+	for _, url := range n.RPCUrls {
+		if url != n.RPCUrl {
+			endpoints = append(endpoints, url)
+		}
+	}
+	return endpoints
+}
+
+// FallbackRPCUrls returns the endpoints to use when RPCUrl is unavailable.
+func (n *ChainConfig) FallbackRPCUrls() []string {
+	endpoints := n.RPCEndpoints()
+	if len(endpoints) < 2 {
+		return nil
+	}
+	return endpoints[1:]
+}
+
+// Logger returns log with the chain name and id attached to every line.
+func (n *ChainConfig) Logger(log log.Logger) log.Logger {
+	return log.New("chain", n.Name, "chainID", n.ChainID)
+}
+
+// WatchesToken reports whether the token API serves the token contract.
+func (n *ChainConfig) WatchesToken(address common.Address) bool {
+	if len(n.Tokens) == 0 {
+		return true
+	}
+	for _, token := range n.Tokens {
+		if common.HexToAddress(token) == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *ChainConfig) rpcHeaders() http.Header {
+	if len(n.RPCHeaders) == 0 {
+		return nil
+	}
+	headers := make(http.Header, len(n.RPCHeaders))
+	for name, value := range n.RPCHeaders {
+		headers.Set(name, value)
+	}
+	return headers
+}
+
+// rateLimiter returns the limiter shared by all the RPC clients of the chain,
+// nil when unlimited.
+func (n *ChainConfig) rateLimiter() *rate.Limiter {
+	n.limiterOnce.Do(func() {
+		if n.RPCRateLimit > 0 {
+			n.limiter = rate.NewLimiter(rate.Limit(n.RPCRateLimit), max(1, int(math.Ceil(n.RPCRateLimit))))
+		}
+	})
+	return n.limiter
+}
+
+func (n *ChainConfig) concurrency() int {
+	if n.Concurrency == 0 {
+		return defaultConcurrency
+	}
+	return n.Concurrency
+}
+
+func (n *ChainConfig) maxRetries() int {
+	switch {
+	case n.MaxRetries == 0:
+		return defaultMaxRetries
+	case n.MaxRetries < 0:
+		return 0
+	}
+	return n.MaxRetries
+}
+
+func (n *ChainConfig) MaxResponseSizeOrDefault() int64 {
+	if n.MaxResponseSize == 0 {
+		return defaultMaxResponseSize
+	}
+	return n.MaxResponseSize
+}
+
+func (n *ChainConfig) ReorgDepthOrDefault() uint64 {
+	if n.ReorgDepth == 0 {
+		return defaultReorgDepth
+	}
+	return n.ReorgDepth
+}
+
+func (n *ChainConfig) MempoolTTLOrDefault() time.Duration {
+	if n.MempoolTTL == 0 {
+		return defaultMempoolTTL
+	}
+	return time.Duration(n.MempoolTTL)
+}
+
+func (n *ChainConfig) PruneIntervalOrDefault() time.Duration {
+	if n.PruneInterval == 0 {
+		return defaultPruneInterval
+	}
+	return time.Duration(n.PruneInterval)
+}
+
+// RetainedBlocks returns how many of the latest blocks are kept, never fewer
+// than the unconfirmed ones and the ones a reorg may replace. Zero keeps them all.
+func (n *ChainConfig) RetainedBlocks() uint64 {
+	if n.RetentionBlocks == 0 {
+		return 0
+	}
+	return max(n.RetentionBlocks, n.Confirmations, n.ReorgDepthOrDefault())
+}
+
+func (n *ChainConfig) PollIntervalOrDefault() time.Duration {
+	if n.PollInterval == nil {
+		return defaultPollInterval
+	}
+	return time.Duration(*n.PollInterval)
+}
+
+func (n *ChainConfig) MaxLogsBlockRangeOrDefault() uint64 {
+	if n.MaxLogsBlockRange == 0 {
+		return defaultMaxLogsBlockRange
+	}
+	return n.MaxLogsBlockRange
 }
 
 func (n *ChainConfig) dockerRepo() string {
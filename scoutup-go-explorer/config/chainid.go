@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blockscout/scoutup/utils"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const chainIDTimeout = 10 * time.Second
+
+// VerifyChainIDs compares the configured chain ids with eth_chainId returned by
+// every chain's RPC. Chains configured without a chain id get the one reported
+// by the RPC. A mismatch is an error unless warnOnly is set.
+func (n *NetworkConfig) VerifyChainIDs(ctx context.Context, log log.Logger, warnOnly bool) error {
+	var errs []error
+	for _, chain := range n.Chains {
+		rpcChainID, err := fetchChainID(ctx, log, chain)
+		if err != nil {
+			if chain.ChainID == 0 {
+				errs = append(errs, fmt.Errorf("%s: cannot detect chain id: %w", chain.Name, err))
+			} else {
+				log.Warn("Cannot verify chain id", "chain", chain.Name, "err", err)
+			}
+			continue
+		}
+
+		switch {
+		case chain.ChainID == 0:
+			log.Info("Detected chain id", "chain", chain.Name, "chainID", rpcChainID)
+			chain.ChainID = rpcChainID
+		case chain.ChainID != rpcChainID:
+			if warnOnly {
+				log.Warn("Configured chain id does not match the RPC", "chain", chain.Name, "configured", chain.ChainID, "rpc", rpcChainID)
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s: configured chain id %d does not match %d reported by %s", chain.Name, chain.ChainID, rpcChainID, utils.RedactURL(chain.RPCUrl)))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	// detected chain ids may clash with the configured ones
+	return n.Validate()
+}
+
+func fetchChainID(ctx context.Context, log log.Logger, chain *ChainConfig) (uint64, error) {
+	if result := chain.preflight; result != nil {
+		chain.preflight = nil
+		return result.ChainID, result.Err
+	}
+	ctx, cancel := context.WithTimeout(ctx, chainIDTimeout)
+	defer cancel()
+
+	client, err := chain.DialRPC(ctx, log)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	var chainID hexutil.Uint64
+	if err := client.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return 0, err
+	}
+	return uint64(chainID), nil
+}
@@ -1,6 +1,10 @@
 package config
 
-import "github.com/urfave/cli/v2"
+import (
+	opservice "github.com/ethereum-optimism/optimism/op-service"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	"github.com/urfave/cli/v2"
+)
 
 const (
 	Anvil                = "anvil"
@@ -9,10 +13,21 @@ const (
 	StartingFrontendPort = "frontend.starting.port"
 	StartingBackendPort  = "backend.starting.port"
 	StartingPostgresPort = "postgres.starting.port"
+	ConfigFile           = "config"
+	ChainIDWarnOnly      = "chainid.warn-only"
+	Reindex              = "reindex"
+	APIAddr              = "api.addr"
+	AdminToken           = "admin-token"
+	ShutdownTimeout      = "shutdown.timeout"
+	RewriteDockerHost    = "rewrite-docker-host"
+	Chain                = "chain"
 )
 
+// EnvVarPrefix prefixes the environment variables of the flags that have one, e.g. SCOUTUP_LOG_LEVEL
+const EnvVarPrefix = "SCOUTUP"
+
 func BaseCLIFlags() []cli.Flag {
-	return []cli.Flag{
+	flags := []cli.Flag{
 		&cli.BoolFlag{
 			Name:  Anvil,
 			Value: false,
@@ -28,6 +43,43 @@ func BaseCLIFlags() []cli.Flag {
 			Value: "http://localhost:8420",
 			Usage: "Admin RPC URL for supersim",
 		},
+		&cli.StringFlag{
+			Name:    ConfigFile,
+			Usage:   "Path to a YAML or JSON network config file (defaults to the built-in anvil config)",
+			EnvVars: opservice.PrefixEnvVar(EnvVarPrefix, "CONFIG"),
+		},
+		&cli.BoolFlag{
+			Name:  ChainIDWarnOnly,
+			Value: false,
+			Usage: "Only warn instead of failing when a configured chain id does not match the RPC",
+		},
+		&cli.BoolFlag{
+			Name:    Reindex,
+			Value:   false,
+			Usage:   "Removes the previously indexed data and indexes the chains again from their first block",
+			EnvVars: opservice.PrefixEnvVar(EnvVarPrefix, "REINDEX"),
+		},
+		&cli.StringFlag{
+			Name:    APIAddr,
+			Aliases: []string{"listen"},
+			Usage:   "Listen address of the REST API (overrides apiListenAddr of the config file, defaults to " + defaultAPIListenAddr + ")",
+			EnvVars: opservice.PrefixEnvVar(EnvVarPrefix, "API_ADDR"),
+		},
+		&cli.StringFlag{
+			Name:    AdminToken,
+			Usage:   "Bearer token of the admin endpoints such as POST /abi, disabled without one (overrides adminToken of the config file)",
+			EnvVars: opservice.PrefixEnvVar(EnvVarPrefix, "ADMIN_TOKEN"),
+		},
+		&cli.BoolFlag{
+			Name:    RewriteDockerHost,
+			Usage:   "Reaches the host.docker.internal RPC urls over localhost when scoutup does not run in docker (overrides rewriteDockerHost of the config file)",
+			EnvVars: opservice.PrefixEnvVar(EnvVarPrefix, "REWRITE_DOCKER_HOST"),
+		},
+		&cli.DurationFlag{
+			Name:  ShutdownTimeout,
+			Value: defaultShutdownTimeout,
+			Usage: "How long the Blockscout instances get to stop gracefully before they are killed",
+		},
 		&cli.Uint64Flag{
 			Name:  StartingFrontendPort,
 			Value: 3000,
@@ -44,4 +96,27 @@ func BaseCLIFlags() []cli.Flag {
 			Usage: "Starting port to increment for postgres containers",
 		},
 	}
+	return append(flags, logCLIFlags()...)
+}
+
+// SnapshotCLIFlags are the flags of the snapshot subcommands.
+func SnapshotCLIFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  Chain,
+			Usage: "Name of the chain in the config, may be omitted with a single chain",
+		},
+	}
+}
+
+// logCLIFlags are the op-service logging flags, --log.level also being
+// accepted as --log-level.
+func logCLIFlags() []cli.Flag {
+	flags := oplog.CLIFlags(EnvVarPrefix)
+	for _, flag := range flags {
+		if level, ok := flag.(*cli.GenericFlag); ok && level.Name == oplog.LevelFlagName {
+			level.Aliases = append(level.Aliases, "log-level")
+		}
+	}
+	return flags
 }
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/blockscout/scoutup/utils"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const dockerHost = "host.docker.internal"
+
+// RewriteDockerHosts makes scoutup reach the RPC urls on host.docker.internal
+// over localhost when rewriteDockerHost is set and scoutup does not run in a
+// container itself, where that name usually does not resolve. Blockscout runs
+// in docker and keeps the urls as configured.
+func (n *NetworkConfig) RewriteDockerHosts(log log.Logger) {
+	if !n.RewriteDockerHost {
+		return
+	}
+	if runningInDocker() {
+		log.Info("Running in a container, keeping " + dockerHost + " in the RPC urls")
+		return
+	}
+	for _, chain := range n.Chains {
+		chain.localRPC = true
+		for _, endpoint := range chain.RPCEndpoints() {
+			if rewritten := localDockerHost(endpoint); rewritten != endpoint {
+				chain.Logger(log).Info("Reaching the RPC over localhost", "url", utils.RedactURL(rewritten))
+			}
+		}
+	}
+}
+
+// runningInDocker reports whether the process runs in a docker, podman or
+// kubernetes container.
+func runningInDocker() bool {
+	for _, path := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	cgroup, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return false
+	}
+	for _, runtime := range []string{"docker", "containerd", "kubepods", "libpod"} {
+		if strings.Contains(string(cgroup), runtime) {
+			return true
+		}
+	}
+	return false
+}
+
+// localDockerHost returns the url with host.docker.internal replaced by
+// localhost, other urls as is.
+func localDockerHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() != dockerHost {
+		return rawURL
+	}
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort("localhost", port)
+	} else {
+		u.Host = "localhost"
+	}
+	return u.String()
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ApplyEnvOverrides overrides chain config fields with SCOUTUP_CHAIN_<index>_<FIELD>
+// environment variables, e.g. SCOUTUP_CHAIN_0_RPC_URL. Unset or empty variables
+// leave the configured values untouched.
+func ApplyEnvOverrides(cfg *NetworkConfig) error {
+	var errs []error
+	for i, chain := range cfg.Chains {
+		if chain == nil {
+			continue
+		}
+
+		if v, ok := chainEnv(i, "NAME"); ok {
+			chain.Name = v
+		}
+		if v, ok := chainEnv(i, "RPC_URL"); ok {
+			chain.RPCUrl = v
+		}
+		if v, ok := chainEnv(i, "WS_URL"); ok {
+			chain.WSUrl = v
+		}
+		if err := uint64ChainEnv(i, "CHAIN_ID", &chain.ChainID); err != nil {
+			errs = append(errs, err)
+		}
+		if err := uint64ChainEnv(i, "FIRST_BLOCK", &chain.FirstBlock); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func chainEnvName(index int, field string) string {
+	return fmt.Sprintf("%s_CHAIN_%d_%s", EnvVarPrefix, index, field)
+}
+
+func chainEnv(index int, field string) (string, bool) {
+	v, ok := os.LookupEnv(chainEnvName(index, field))
+	return v, ok && v != ""
+}
+
+func uint64ChainEnv(index int, field string, dst *uint64) error {
+	v, ok := chainEnv(index, field)
+	if !ok {
+		return nil
+	}
+	parsed, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s=%q: %w", chainEnvName(index, field), v, err)
+	}
+	*dst = parsed
+	return nil
+}
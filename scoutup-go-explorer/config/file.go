@@ -0,0 +1,115 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadNetworkConfig reads the network config from a YAML or JSON file, the
+// format being detected by the file extension. An empty path falls back to
+// the default anvil config.
+func LoadNetworkConfig(path string) (*NetworkConfig, error) {
+	if path == "" {
+		return PrepareDefaultAnvilConfig(), nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve config path %s: %w", path, err)
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file: %w", err)
+	}
+
+	var networkConfig NetworkConfig
+	switch ext := strings.ToLower(filepath.Ext(absPath)); ext {
+	case ".yaml", ".yml":
+		err = decodeYAML(data, &networkConfig)
+	case ".json":
+		err = decodeJSON(data, &networkConfig)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q (expected .yaml, .yml or .json)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse config file %s: %w", absPath, err)
+	}
+
+	for _, chain := range networkConfig.Chains {
+		if chain != nil && chain.RPCUrl == "" && len(chain.RPCUrls) > 0 {
+			chain.RPCUrl = chain.RPCUrls[0]
+		}
+	}
+
+	if err := networkConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", absPath, err)
+	}
+
+	for _, path := range []*string{&networkConfig.TLSCertPath, &networkConfig.TLSKeyPath} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(filepath.Dir(absPath), *path)
+		}
+	}
+
+	var errs []error
+	for i, chain := range networkConfig.Chains {
+		if err := chain.loadABIs(filepath.Dir(absPath)); err != nil {
+			errs = append(errs, fmt.Errorf("chains[%d] (%s): %w", i, chain.Name, err))
+		}
+		if err := chain.loadGenesisAlloc(filepath.Dir(absPath)); err != nil {
+			errs = append(errs, fmt.Errorf("chains[%d] (%s): %w", i, chain.Name, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", absPath, err)
+	}
+
+	return &networkConfig, nil
+}
+
+// yaml errors already mention the offending line, e.g. "yaml: line 3: ..."
+func decodeYAML(data []byte, out *NetworkConfig) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+func decodeJSON(data []byte, out *NetworkConfig) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(out)
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, column := offsetToPosition(data, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %w", line, column, err)
+	case errors.As(err, &typeErr):
+		line, column := offsetToPosition(data, typeErr.Offset)
+		return fmt.Errorf("line %d, column %d: %w", line, column, err)
+	}
+	return err
+}
+
+func offsetToPosition(data []byte, offset int64) (line int, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const firstBlockTimeout = 10 * time.Second
+
+// ResolveFirstBlocks sets FirstBlock of the chains configured with a
+// FirstBlockHash to the number of that block. The block has to be canonical,
+// and a FirstBlock set along with the hash has to be its number.
+func (n *NetworkConfig) ResolveFirstBlocks(ctx context.Context, log log.Logger) error {
+	var errs []error
+	for _, chain := range n.Chains {
+		if chain.FirstBlockHash == "" {
+			continue
+		}
+		number, err := resolveBlockHash(ctx, log, chain, common.HexToHash(chain.FirstBlockHash))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: cannot resolve firstBlockHash: %w", chain.Name, err))
+			continue
+		}
+		if chain.FirstBlock != 0 && chain.FirstBlock != number {
+			errs = append(errs, fmt.Errorf("%s: firstBlock %d conflicts with firstBlockHash %s of block %d", chain.Name, chain.FirstBlock, chain.FirstBlockHash, number))
+			continue
+		}
+		chain.Logger(log).Info("Resolved first block", "hash", chain.FirstBlockHash, "number", number)
+		chain.FirstBlock = number
+	}
+	return errors.Join(errs...)
+}
+
+func resolveBlockHash(ctx context.Context, log log.Logger, chain *ChainConfig, hash common.Hash) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, firstBlockTimeout)
+	defer cancel()
+
+	client, err := chain.DialRPC(ctx, log)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	type header struct {
+		Number hexutil.Uint64 `json:"number"`
+		Hash   common.Hash    `json:"hash"`
+	}
+	var block *header
+	if err := client.CallContext(ctx, &block, "eth_getBlockByHash", hash, false); err != nil {
+		return 0, err
+	}
+	if block == nil {
+		return 0, fmt.Errorf("block %s not found", hash)
+	}
+
+	// nodes also serve the blocks that were reorged out by their hash
+	var canonical *header
+	if err := client.CallContext(ctx, &canonical, "eth_getBlockByNumber", block.Number, false); err != nil {
+		return 0, err
+	}
+	if canonical == nil || canonical.Hash != hash {
+		return 0, fmt.Errorf("block %s is no longer canonical at height %d", hash, uint64(block.Number))
+	}
+	return uint64(block.Number), nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// loadGenesisAlloc parses the alloc of the chain's genesis file into
+// GenesisAlloc and passes the file on to Blockscout as its chain spec, with
+// the balances written as hex quantities. A relative path is resolved against dir.
+func (n *ChainConfig) loadGenesisAlloc(dir string) error {
+	if n.GenesisAllocPath == "" {
+		return nil
+	}
+	path := n.GenesisAllocPath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("genesisAllocPath: %w", err)
+	}
+
+	genesis, alloc, err := parseGenesisAlloc(data)
+	if err != nil {
+		return fmt.Errorf("genesisAllocPath: cannot parse %s: %w", path, err)
+	}
+	normalized, err := json.Marshal(genesis)
+	if err != nil {
+		return fmt.Errorf("genesisAllocPath: %w", err)
+	}
+	n.GenesisJSON = normalized
+	n.GenesisAlloc = alloc
+	return nil
+}
+
+// parseGenesisAlloc checks every account of the alloc, which geth writes with
+// or without the 0x prefix and with hex or decimal balances, and returns the
+// genesis with the accounts rewritten in the 0x-prefixed hex form.
+func parseGenesisAlloc(data []byte) (map[string]json.RawMessage, map[common.Address]*big.Int, error) {
+	var genesis map[string]json.RawMessage
+	if err := json.Unmarshal(data, &genesis); err != nil {
+		return nil, nil, err
+	}
+	if _, ok := genesis["alloc"]; !ok {
+		return nil, nil, errors.New("missing alloc")
+	}
+	var accounts map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(genesis["alloc"], &accounts); err != nil {
+		return nil, nil, fmt.Errorf("alloc: %w", err)
+	}
+
+	var errs []error
+	alloc := make(map[common.Address]*big.Int, len(accounts))
+	normalized := make(map[string]map[string]json.RawMessage, len(accounts))
+	for key, account := range accounts {
+		if !common.IsHexAddress(key) {
+			errs = append(errs, fmt.Errorf("alloc: invalid address %q", key))
+			continue
+		}
+		address := common.HexToAddress(key)
+		if _, ok := alloc[address]; ok {
+			errs = append(errs, fmt.Errorf("alloc: address %s is listed twice", address.Hex()))
+			continue
+		}
+
+		balance := new(big.Int)
+		if raw, ok := account["balance"]; ok {
+			var parsed math.HexOrDecimal256
+			if err := json.Unmarshal(raw, &parsed); err != nil {
+				errs = append(errs, fmt.Errorf("alloc[%s]: invalid balance %s", key, raw))
+				continue
+			}
+			balance = (*big.Int)(&parsed)
+		}
+		account["balance"], _ = json.Marshal((*hexutil.Big)(balance))
+		alloc[address] = balance
+		normalized[address.Hex()] = account
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+
+	var err error
+	genesis["alloc"], err = json.Marshal(normalized)
+	if err != nil {
+		return nil, nil, err
+	}
+	return genesis, alloc, nil
+}
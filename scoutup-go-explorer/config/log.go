@@ -0,0 +1,23 @@
+package config
+
+import (
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	"github.com/urfave/cli/v2"
+)
+
+// LogConfig returns the logging config of the --log.* flags and their env
+// vars, falling back to logLevel and logFormat of the network config.
+func (n *NetworkConfig) LogConfig(ctx *cli.Context) oplog.CLIConfig {
+	cfg := oplog.ReadCLIConfig(ctx)
+	if n == nil {
+		return cfg
+	}
+	if n.LogLevel != "" && !ctx.IsSet(oplog.LevelFlagName) {
+		// validated along with the config
+		cfg.Level, _ = oplog.LevelFromString(n.LogLevel)
+	}
+	if n.LogFormat != "" && !ctx.IsSet(oplog.FormatFlagName) {
+		cfg.Format = oplog.FormatType(n.LogFormat)
+	}
+	return cfg
+}
@@ -1,12 +1,84 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultAPIListenAddr   = "127.0.0.1:4100"
+	defaultShutdownTimeout = 5 * time.Minute
+	defaultAPICacheSize    = 1024
+)
 
 type NetworkConfig struct {
-	Chains               []*ChainConfig
-	StartingFrontendPort uint64
-	StartingBackendPort  uint64
-	StartingPostgresPort uint64
+	Chains []*ChainConfig `yaml:"chains" json:"chains"`
+	// Address of the REST API serving the indexed data
+	APIListenAddr string `yaml:"apiListenAddr" json:"apiListenAddr"`
+	// Blocks, and as many transactions, cached by the REST API, 1024 when unset, negative disables the cache
+	APICacheSize int `yaml:"apiCacheSize" json:"apiCacheSize"`
+	// debug, info, warn or error, the --log.level flag takes precedence
+	LogLevel string `yaml:"logLevel" json:"logLevel"`
+	// text, terminal, logfmt or json, the --log.format flag takes precedence
+	LogFormat string `yaml:"logFormat" json:"logFormat"`
+	// Reach host.docker.internal RPC urls over localhost when scoutup does not run in docker,
+	// the --rewrite-docker-host flag takes precedence
+	RewriteDockerHost bool `yaml:"rewriteDockerHost" json:"rewriteDockerHost"`
+	// PEM certificate and key of the REST API, it serves HTTPS when both are set.
+	// Relative to the config file, the certificate is read again on SIGHUP
+	TLSCertPath string `yaml:"tlsCertPath" json:"tlsCertPath"`
+	TLSKeyPath  string `yaml:"tlsKeyPath" json:"tlsKeyPath"`
+	// What happens on start to a chain whose RPC is unreachable: strict aborts the start,
+	// lenient (the default) starts the other chains and keeps retrying it
+	Preflight string `yaml:"preflight" json:"preflight"`
+	// Bearer token of the endpoints changing the served data, e.g. POST /abi, which are
+	// disabled when unset. The --admin-token flag takes precedence
+	AdminToken string `yaml:"adminToken" json:"adminToken"`
+
+	StartingFrontendPort uint64        `yaml:"-" json:"-"`
+	StartingBackendPort  uint64        `yaml:"-" json:"-"`
+	StartingPostgresPort uint64        `yaml:"-" json:"-"`
+	Reindex              bool          `yaml:"-" json:"-"`
+	ShutdownTimeout      time.Duration `yaml:"-" json:"-"`
+}
+
+func (n *NetworkConfig) APIAddr() string {
+	if n.APIListenAddr == "" {
+		return defaultAPIListenAddr
+	}
+	return n.APIListenAddr
+}
+
+// TLSEnabled reports whether the REST API serves HTTPS.
+func (n *NetworkConfig) TLSEnabled() bool {
+	return n.TLSCertPath != "" && n.TLSKeyPath != ""
+}
+
+func (n *NetworkConfig) APICacheSizeOrDefault() int {
+	if n.APICacheSize == 0 {
+		return defaultAPICacheSize
+	}
+	return n.APICacheSize
+}
+
+func (n *NetworkConfig) shutdownTimeout() time.Duration {
+	if n.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return n.ShutdownTimeout
+}
+
+func (n *NetworkConfig) instanceConfig(chain *ChainConfig, frontendPort, backendPort, postgresPort uint64) *InstanceConfig {
+	return &InstanceConfig{
+		FrontendPort:      frontendPort,
+		BackendPort:       backendPort,
+		PostgresPort:      postgresPort,
+		Reindex:           n.Reindex,
+		ShutdownTimeout:   n.shutdownTimeout(),
+		DockerRepo:        chain.dockerRepo(),
+		DockerTag:         chain.dockerTag(),
+		FrontendDockerTag: chain.frontendDockerTag(),
+	}
 }
 
 func (n *NetworkConfig) PrepareBlockscoutConfigs() []*BlockscoutConfig {
@@ -17,15 +89,8 @@ func (n *NetworkConfig) PrepareBlockscoutConfigs() []*BlockscoutConfig {
 	configs := []*BlockscoutConfig{}
 	for _, chain := range n.Chains {
 		config := &BlockscoutConfig{
-			ChainConfig: chain,
-			InstanceConfig: &InstanceConfig{
-				FrontendPort:      frontendPort,
-				BackendPort:       backendPort,
-				PostgresPort:      postgresPort,
-				DockerRepo:        chain.dockerRepo(),
-				DockerTag:         chain.dockerTag(),
-				FrontendDockerTag: chain.frontendDockerTag(),
-			},
+			ChainConfig:    chain,
+			InstanceConfig: n.instanceConfig(chain, frontendPort, backendPort, postgresPort),
 		}
 
 		if config.OPConfig != nil {
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blockscout/scoutup/utils"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	PreflightStrict  = "strict"
+	PreflightLenient = "lenient"
+
+	preflightTimeout = 5 * time.Second
+)
+
+// PreflightResult is what a chain's RPC reported on start.
+type PreflightResult struct {
+	Chain *ChainConfig
+	// the endpoint checked last, without its password
+	URL     string
+	ChainID uint64
+	Head    uint64
+	Latency time.Duration
+	// nil when the RPC answered both calls
+	Err error
+}
+
+func (r *PreflightResult) Reachable() bool {
+	return r.Err == nil
+}
+
+func (n *NetworkConfig) PreflightStrict() bool {
+	return n.Preflight == PreflightStrict
+}
+
+// Preflight calls eth_chainId and eth_blockNumber on every chain's RPC, in
+// parallel and with a short timeout, so that an unreachable one is reported
+// up front rather than by the indexer retrying it.
+func Preflight(ctx context.Context, log log.Logger, chains []*ChainConfig) []*PreflightResult {
+	results := make([]*PreflightResult, len(chains))
+	var wg sync.WaitGroup
+	for i, chain := range chains {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = preflight(ctx, log, chain)
+			chain.preflight = results[i]
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func preflight(ctx context.Context, log log.Logger, chain *ChainConfig) *PreflightResult {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+	result := &PreflightResult{Chain: chain}
+	if endpoints := chain.RPCEndpoints(); len(endpoints) > 0 {
+		result.URL = utils.RedactURL(endpoints[0])
+	}
+	start := time.Now()
+	defer func() { result.Latency = time.Since(start) }()
+
+	client, err := chain.DialRPC(ctx, log)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer client.Close()
+	// the client fails over across the endpoints
+	defer func() { result.URL = client.URL() }()
+
+	var chainID, head hexutil.Uint64
+	if err := client.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		result.Err = preflightError(err)
+		return result
+	}
+	if err := client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+		result.Err = preflightError(err)
+		return result
+	}
+	result.ChainID, result.Head = uint64(chainID), uint64(head)
+	return result
+}
+
+func preflightError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("no answer within %s", preflightTimeout)
+	}
+	return err
+}
+
+// PreflightReport formats the results as a table, one chain per row.
+func PreflightReport(results []*PreflightResult) string {
+	var b strings.Builder
+	fmt.Fprintln(&b, "\nRPC Preflight:")
+	fmt.Fprintln(&b, "--------------")
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAIN\tRPC\tCHAIN ID\tHEAD\tLATENCY\tSTATUS")
+	for _, result := range results {
+		chain, rpc := result.Chain, result.URL
+		latency := result.Latency.Round(time.Millisecond)
+		switch {
+		case !result.Reachable():
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t%s\tunreachable: %v\n", chain.Name, rpc, latency, result.Err)
+		case chain.ChainID != 0 && chain.ChainID != result.ChainID:
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\tchain id mismatch, configured %d\n", chain.Name, rpc, result.ChainID, result.Head, latency, chain.ChainID)
+		default:
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\tok\n", chain.Name, rpc, result.ChainID, result.Head, latency)
+		}
+	}
+	w.Flush()
+	return b.String()
+}
+
+// PreflightError joins the errors of the unreachable chains, nil when all
+// of them answered.
+func PreflightError(results []*PreflightResult) error {
+	var errs []error
+	for _, result := range results {
+		if !result.Reachable() {
+			errs = append(errs, fmt.Errorf("%s: %w", result.Chain.Name, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HoldBackUnreachable removes from the config the chains that cannot be
+// started before their RPC answers, because their chain id is not configured,
+// and returns them. The others start as usual, retrying their RPC themselves.
+func (n *NetworkConfig) HoldBackUnreachable(results []*PreflightResult) ([]*ChainConfig, error) {
+	var held, kept []*ChainConfig
+	var errs []error
+	for _, result := range results {
+		switch {
+		case result.Reachable() || result.Chain.ChainID != 0:
+			kept = append(kept, result.Chain)
+		case result.Chain.OPConfig != nil:
+			// its instance is linked to the others on start
+			errs = append(errs, fmt.Errorf("%s: cannot detect chain id of a chain with opConfig: %w", result.Chain.Name, result.Err))
+		default:
+			held = append(held, result.Chain)
+		}
+	}
+	if len(kept) == 0 && len(errs) == 0 {
+		errs = append(errs, errors.New("no chain is reachable"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	n.Chains = kept
+	return held, nil
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Reload holds the changes of a reloaded config to the running chains, the
+// chains being matched by name.
+type Reload struct {
+	// the new chains, on ports not used by the running ones
+	Added []*BlockscoutConfig
+	// the running chains no longer configured
+	Removed []*BlockscoutConfig
+	// the new configs of the running chains that changed, on the same ports
+	Changed []*BlockscoutConfig
+}
+
+func (r *Reload) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// PrepareReload compares the reloaded config with the running chains. A chain
+// id change of a running chain is rejected, and so is any change to the chains
+// with opConfig, as their instances are linked to each other on start.
+func (n *NetworkConfig) PrepareReload(running []*BlockscoutConfig) (*Reload, error) {
+	frontendPort := n.StartingFrontendPort
+	backendPort := n.StartingBackendPort
+	postgresPort := n.StartingPostgresPort
+	byName := make(map[string]*BlockscoutConfig)
+	for _, config := range running {
+		byName[config.Name] = config
+		frontendPort = max(frontendPort, config.FrontendPort+1)
+		backendPort = max(backendPort, config.BackendPort+1)
+		postgresPort = max(postgresPort, config.PostgresPort+1)
+	}
+
+	reload := &Reload{}
+	var errs []error
+	configured := make(map[string]bool)
+	for _, chain := range n.Chains {
+		configured[chain.Name] = true
+		old, ok := byName[chain.Name]
+		switch {
+		case !ok && chain.OPConfig != nil:
+			errs = append(errs, fmt.Errorf("%s: chains with opConfig cannot be added without a restart", chain.Name))
+		case !ok:
+			reload.Added = append(reload.Added, &BlockscoutConfig{
+				ChainConfig:    chain,
+				InstanceConfig: n.instanceConfig(chain, frontendPort, backendPort, postgresPort),
+			})
+			frontendPort++
+			backendPort++
+			postgresPort++
+		case old.ChainID != chain.ChainID:
+			errs = append(errs, fmt.Errorf("%s: chainId cannot change from %d to %d without a restart", chain.Name, old.ChainID, chain.ChainID))
+		case sameChain(old.ChainConfig, chain):
+		case chain.OPConfig != nil || old.OPConfig != nil:
+			errs = append(errs, fmt.Errorf("%s: chains with opConfig cannot be changed without a restart", chain.Name))
+		default:
+			reload.Changed = append(reload.Changed, &BlockscoutConfig{
+				ChainConfig:    chain,
+				InstanceConfig: n.instanceConfig(chain, old.FrontendPort, old.BackendPort, old.PostgresPort),
+			})
+		}
+	}
+	for _, config := range running {
+		switch {
+		case configured[config.Name]:
+		case config.OPConfig != nil:
+			errs = append(errs, fmt.Errorf("%s: chains with opConfig cannot be removed without a restart", config.Name))
+		default:
+			reload.Removed = append(reload.Removed, config)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return reload, nil
+}
+
+// sameChain compares the configured fields of the chains.
+func sameChain(a, b *ChainConfig) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJSON, bJSON)
+}
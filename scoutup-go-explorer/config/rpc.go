@@ -0,0 +1,32 @@
+package config
+
+import (
+	"context"
+
+	"github.com/blockscout/scoutup/metrics"
+	"github.com/blockscout/scoutup/rpcclient"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DialRPC returns a client over the chain's RPC endpoints configured with the
+// chain's RPC settings.
+func (n *ChainConfig) DialRPC(ctx context.Context, log log.Logger) (*rpcclient.Client, error) {
+	opts := rpcclient.Options{
+		BatchSize:       n.BatchSize,
+		MaxRetries:      n.maxRetries(),
+		MaxResponseSize: n.MaxResponseSizeOrDefault(),
+		Limiter:         n.rateLimiter(),
+		Headers:         n.rpcHeaders(),
+	}
+	// the chain id is not known yet while it is being detected
+	if n.ChainID != 0 {
+		opts.Metrics = metrics.ForChain(n.ChainID, n.Name)
+	}
+	endpoints := n.RPCEndpoints()
+	if n.localRPC {
+		for i, endpoint := range endpoints {
+			endpoints[i] = localDockerHost(endpoint)
+		}
+	}
+	return rpcclient.Dial(ctx, log, endpoints, opts)
+}
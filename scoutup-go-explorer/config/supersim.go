@@ -18,6 +18,8 @@ func PrepareSupersimConfig(admingRPCUrl string) (*NetworkConfig, error) {
 		RPCUrl:      fmt.Sprintf("http://host.docker.internal:%d", sc.L1Config.Port),
 		ChainID:     sc.L1Config.ChainID,
 		GenesisJSON: sc.L1Config.GenesisJSON,
+		// the supersim chains are anvil instances
+		EnableTraces: true,
 	}
 	if sc.L1Config.ForkConfig != nil && sc.L1Config.ForkConfig.BlockNumber > 0 {
 		l1Config.FirstBlock = sc.L1Config.ForkConfig.BlockNumber
@@ -43,7 +45,8 @@ func PrepareSupersimConfig(admingRPCUrl string) (*NetworkConfig, error) {
 				L1RPCUrl:               fmt.Sprintf("http://host.docker.internal:%d", sc.L1Config.Port),
 				L1SystemConfigContract: chain.L2Config.L1Addresses.SystemConfigProxy.String(),
 			},
-			GenesisJSON: chain.GenesisJSON,
+			GenesisJSON:  chain.GenesisJSON,
+			EnableTraces: true,
 		}
 
 		if chain.ForkConfig != nil && chain.ForkConfig.BlockNumber > 0 {
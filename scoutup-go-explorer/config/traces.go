@@ -0,0 +1,47 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/blockscout/scoutup/rpcclient"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const tracingTimeout = 30 * time.Second
+
+// VerifyTracing checks that the node of every chain with EnableTraces serves
+// debug_traceBlockByNumber. Tracing is turned off for the chains whose node
+// does not have the method, so that Blockscout does not keep failing on it.
+func (n *NetworkConfig) VerifyTracing(ctx context.Context, log log.Logger) {
+	for _, chain := range n.Chains {
+		if !chain.EnableTraces {
+			continue
+		}
+		err := traceLatestBlock(ctx, log, chain)
+		switch {
+		case err == nil:
+		case rpcclient.IsMethodNotFound(err):
+			chain.Logger(log).Warn("Node does not support debug_traceBlockByNumber, internal transactions are not indexed", "err", err)
+			chain.EnableTraces = false
+		default:
+			// e.g. the latest block cannot be traced yet, the node may still support the method
+			chain.Logger(log).Warn("Cannot verify tracing support", "err", err)
+		}
+	}
+}
+
+func traceLatestBlock(ctx context.Context, log log.Logger, chain *ChainConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, tracingTimeout)
+	defer cancel()
+
+	client, err := chain.DialRPC(ctx, log)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var traces json.RawMessage
+	return client.CallContext(ctx, &traces, "debug_traceBlockByNumber", "latest", map[string]string{"tracer": "callTracer"})
+}
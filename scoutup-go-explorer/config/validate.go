@@ -0,0 +1,231 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/blockscout/scoutup/utils"
+	oplog "github.com/ethereum-optimism/optimism/op-service/log"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// the token characters of RFC 7230
+var headerNameRegex = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+
+// Validate checks every chain config and returns all the problems found
+// joined into a single error. A zero chain id is allowed as it can be
+// detected from the RPC by VerifyChainIDs.
+func (n *NetworkConfig) Validate() error {
+	if len(n.Chains) == 0 {
+		return errors.New("no chains configured")
+	}
+
+	var errs []error
+	if n.APIListenAddr != "" {
+		if _, _, err := net.SplitHostPort(n.APIListenAddr); err != nil {
+			errs = append(errs, fmt.Errorf("apiListenAddr: %w", err))
+		}
+	}
+	switch n.Preflight {
+	case "", PreflightStrict, PreflightLenient:
+	default:
+		errs = append(errs, fmt.Errorf("preflight must be %s or %s, got %q", PreflightStrict, PreflightLenient, n.Preflight))
+	}
+	if (n.TLSCertPath == "") != (n.TLSKeyPath == "") {
+		errs = append(errs, errors.New("tlsCertPath and tlsKeyPath must be set together"))
+	}
+	if n.LogLevel != "" {
+		if _, err := oplog.LevelFromString(n.LogLevel); err != nil {
+			errs = append(errs, fmt.Errorf("logLevel: %w", err))
+		}
+	}
+	if n.LogFormat != "" {
+		if err := new(oplog.FormatFlagValue).Set(n.LogFormat); err != nil {
+			errs = append(errs, fmt.Errorf("logFormat: %w", err))
+		}
+	}
+	chainIDs := make(map[uint64]int)
+	names := make(map[string]int)
+	dsns := make(map[string]int)
+	for i, chain := range n.Chains {
+		if chain == nil {
+			errs = append(errs, fmt.Errorf("chains[%d]: empty chain config", i))
+			continue
+		}
+		for _, err := range chain.validate() {
+			errs = append(errs, fmt.Errorf("chains[%d] (%s): %w", i, chain.Name, err))
+		}
+
+		if chain.ChainID != 0 {
+			if j, ok := chainIDs[chain.ChainID]; ok {
+				errs = append(errs, fmt.Errorf("chains[%d] (%s): chainId %d is already used by chains[%d]", i, chain.Name, chain.ChainID, j))
+			} else {
+				chainIDs[chain.ChainID] = i
+			}
+		}
+
+		// Names are compared the way they end up in container names
+		if chain.Name != "" {
+			key := utils.NameToContainerName("", chain.Name)
+			if j, ok := names[key]; ok {
+				errs = append(errs, fmt.Errorf("chains[%d] (%s): name clashes with chains[%d] (%s)", i, chain.Name, j, n.Chains[j].Name))
+			} else {
+				names[key] = i
+			}
+		}
+
+		// Blockscout's schema holds a single chain, so chains cannot share a database
+		if chain.StorageDSN != "" {
+			if j, ok := dsns[chain.StorageDSN]; ok {
+				errs = append(errs, fmt.Errorf("chains[%d] (%s): storageDsn is already used by chains[%d], every chain needs its own database", i, chain.Name, j))
+			} else {
+				dsns[chain.StorageDSN] = i
+			}
+			// scoutup manages the bundled database only
+			if n.Reindex {
+				errs = append(errs, fmt.Errorf("chains[%d] (%s): --reindex cannot drop the data of storageDsn, drop the database manually", i, chain.Name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *ChainConfig) validate() []error {
+	var errs []error
+	if n.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	endpoints := n.RPCEndpoints()
+	if len(endpoints) == 0 {
+		errs = append(errs, errors.New("rpcUrl is required"))
+	}
+	for _, endpoint := range endpoints {
+		if err := validateRPCUrl(endpoint); err != nil {
+			errs = append(errs, fmt.Errorf("rpcUrl: %w", err))
+		}
+	}
+	if n.FirstBlockHash != "" {
+		if len(n.FirstBlockHash) != 66 || !strings.HasPrefix(n.FirstBlockHash, "0x") {
+			errs = append(errs, fmt.Errorf("firstBlockHash: expected a 0x-prefixed 32-byte hash, got %q", n.FirstBlockHash))
+		} else if _, err := hexutil.Decode(n.FirstBlockHash); err != nil {
+			errs = append(errs, fmt.Errorf("firstBlockHash: %w", err))
+		}
+	}
+	if n.WSUrl != "" {
+		if err := validateWSUrl(n.WSUrl); err != nil {
+			errs = append(errs, fmt.Errorf("wsUrl: %w", err))
+		}
+	}
+	if n.StorageDSN != "" {
+		if err := validateStorageDSN(n.StorageDSN); err != nil {
+			errs = append(errs, fmt.Errorf("storageDsn: %w", err))
+		}
+		if n.RetentionBlocks > 0 {
+			errs = append(errs, errors.New("retentionBlocks: only the bundled database can be pruned, unset storageDsn"))
+		}
+	}
+	for _, token := range n.Tokens {
+		if !strings.HasPrefix(token, "0x") || !common.IsHexAddress(token) {
+			errs = append(errs, fmt.Errorf("tokens: invalid address %q", token))
+		}
+	}
+	for address := range n.ABIs {
+		if !strings.HasPrefix(address, "0x") || !common.IsHexAddress(address) {
+			errs = append(errs, fmt.Errorf("abis: invalid address %q", address))
+		}
+	}
+	for name, value := range n.RPCHeaders {
+		if !headerNameRegex.MatchString(name) {
+			errs = append(errs, fmt.Errorf("rpcHeaders: invalid header name %q", name))
+		}
+		if strings.ContainsAny(value, "\r\n\x00") {
+			// the value is not printed, it usually is a credential
+			errs = append(errs, fmt.Errorf("rpcHeaders: invalid value of %s", name))
+		}
+	}
+	if n.RPCRateLimit < 0 {
+		errs = append(errs, fmt.Errorf("rpcRateLimit must not be negative, got %v", n.RPCRateLimit))
+	}
+	if n.PruneInterval < 0 {
+		errs = append(errs, fmt.Errorf("pruneInterval must not be negative, got %v", time.Duration(n.PruneInterval)))
+	}
+	if n.PollInterval != nil && *n.PollInterval < 0 {
+		errs = append(errs, fmt.Errorf("pollInterval must not be negative, got %v", time.Duration(*n.PollInterval)))
+	}
+	// the rate limiter is all that paces the polls then
+	if n.PollInterval != nil && *n.PollInterval == 0 && n.RPCRateLimit == 0 {
+		errs = append(errs, errors.New("pollInterval: 0 requires rpcRateLimit to be set"))
+	}
+	if n.MempoolTTL < 0 {
+		errs = append(errs, fmt.Errorf("mempoolTtl must not be negative, got %v", time.Duration(n.MempoolTTL)))
+	}
+	if n.Concurrency < 0 {
+		errs = append(errs, fmt.Errorf("concurrency must not be negative, got %d", n.Concurrency))
+	}
+	if n.BatchSize < 0 {
+		errs = append(errs, fmt.Errorf("batchSize must not be negative, got %d", n.BatchSize))
+	}
+	if n.MaxResponseSize < 0 {
+		errs = append(errs, fmt.Errorf("maxResponseSize must not be negative, got %d", n.MaxResponseSize))
+	}
+	return errs
+}
+
+func validateRPCUrl(rawURL string) error {
+	if rawURL == "" {
+		return errors.New("is required")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// the error would contain the credentials, if any
+		return errors.New("malformed url")
+	}
+	switch u.Scheme {
+	case "http", "https", "ws", "wss":
+	default:
+		return fmt.Errorf("unsupported scheme %q in %s", u.Scheme, u.Redacted())
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host in %s", u.Redacted())
+	}
+	return nil
+}
+
+func validateWSUrl(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.New("malformed url")
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return fmt.Errorf("unsupported scheme %q in %s", u.Scheme, u.Redacted())
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host in %s", u.Redacted())
+	}
+	return nil
+}
+
+func validateStorageDSN(dsn string) error {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		// the error would contain the password
+		return errors.New("malformed connection string")
+	}
+	switch u.Scheme {
+	case "postgres", "postgresql":
+	case "sqlite", "sqlite3", "file":
+		return errors.New("sqlite is not supported, Blockscout requires PostgreSQL")
+	default:
+		return fmt.Errorf("unsupported scheme %q, expected postgresql://", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("missing host")
+	}
+	return nil
+}
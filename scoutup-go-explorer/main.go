@@ -5,8 +5,10 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 
+	"github.com/blockscout/scoutup/api"
 	"github.com/blockscout/scoutup/blockscout"
 	"github.com/blockscout/scoutup/config"
 	"github.com/ethereum-optimism/optimism/op-service/cliapp"
@@ -33,6 +35,26 @@ func main() {
 			Usage:  "Cleans up all containers and temporary files",
 			Action: ScoutupClean,
 		},
+		{
+			Name:  "snapshot",
+			Usage: "Exports and imports the indexed data of a chain",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "export",
+					Usage:     "Exports the indexed data of a chain to a file",
+					ArgsUsage: "<file>",
+					Flags:     config.SnapshotCLIFlags(),
+					Action:    ScoutupSnapshotExport,
+				},
+				{
+					Name:      "import",
+					Usage:     "Imports a snapshot into the empty database of a chain",
+					ArgsUsage: "<file>",
+					Flags:     config.SnapshotCLIFlags(),
+					Action:    ScoutupSnapshotImport,
+				},
+			},
+		},
 	}
 
 	ctx := ctxinterrupt.WithSignalWaiterMain(context.Background())
@@ -42,7 +64,7 @@ func main() {
 }
 
 func ScoutupMain(ctx *cli.Context, closeApp context.CancelCauseFunc) (cliapp.Lifecycle, error) {
-	log := oplog.NewLogger(oplog.AppOut(ctx), oplog.DefaultCLIConfig())
+	log := newLogger(ctx, nil)
 
 	var networkConfig *config.NetworkConfig
 	if ctx.Bool(config.Supersim) {
@@ -53,16 +75,141 @@ func ScoutupMain(ctx *cli.Context, closeApp context.CancelCauseFunc) (cliapp.Lif
 			return nil, err
 		}
 	} else {
-		networkConfig = config.PrepareDefaultAnvilConfig()
+		var err error
+		networkConfig, err = config.LoadNetworkConfig(ctx.String(config.ConfigFile))
+		if err != nil {
+			log.Crit("Failed to load network config", "err", err)
+			return nil, err
+		}
+	}
+	// the config file may set the log level and format
+	log = newLogger(ctx, networkConfig)
+
+	if err := config.ApplyEnvOverrides(networkConfig); err != nil {
+		log.Crit("Failed to apply environment overrides", "err", err)
+		return nil, err
+	}
+	applyFlags(ctx, networkConfig)
+	networkConfig.Reindex = ctx.Bool(config.Reindex)
+	// the overrides and flags are validated along with the file
+	if err := networkConfig.Validate(); err != nil {
+		log.Crit("Invalid network config", "err", err)
+		return nil, err
+	}
+	networkConfig.RewriteDockerHosts(log)
+
+	results := config.Preflight(ctx.Context, log, networkConfig.Chains)
+	log.Info(config.PreflightReport(results))
+	var pending []*config.ChainConfig
+	if networkConfig.PreflightStrict() {
+		if err := config.PreflightError(results); err != nil {
+			log.Crit("Unreachable chains in strict preflight", "err", err)
+			return nil, err
+		}
+	} else {
+		var err error
+		if pending, err = networkConfig.HoldBackUnreachable(results); err != nil {
+			log.Crit("Failed to hold back unreachable chains", "err", err)
+			return nil, err
+		}
+		for _, chain := range pending {
+			log.Warn("Chain RPC is unreachable, starting the chain once it answers", "chain", chain.Name)
+		}
+	}
+
+	if err := networkConfig.VerifyChainIDs(ctx.Context, log, ctx.Bool(config.ChainIDWarnOnly)); err != nil {
+		log.Crit("Failed to verify chain ids", "err", err)
+		return nil, err
+	}
+
+	if err := networkConfig.ResolveFirstBlocks(ctx.Context, log); err != nil {
+		log.Crit("Failed to resolve first blocks", "err", err)
+		return nil, err
 	}
+	networkConfig.VerifyTracing(ctx.Context, log)
+
+	configs := networkConfig.PrepareBlockscoutConfigs()
+	server := api.NewServer(log, networkConfig.APIAddr(), networkConfig.APICacheSizeOrDefault(), configs)
+	if networkConfig.TLSEnabled() {
+		if err := server.EnableTLS(networkConfig.TLSCertPath, networkConfig.TLSKeyPath); err != nil {
+			log.Crit("Failed to enable TLS", "err", err)
+			return nil, err
+		}
+	}
+	server.SetAdminToken(networkConfig.AdminToken)
+	// the reorged blocks are dropped from the API cache
+	orchestrator, err := blockscout.NewOrchestrator(log, configs, server.Reorg)
+	if err != nil {
+		log.Crit("Failed to prepare Blockscout instances", "err", err)
+		return nil, err
+	}
+	server.SetIndexers(orchestrator)
+
+	scoutup := &Scoutup{
+		log:          log,
+		orchestrator: orchestrator,
+		api:          server,
+		configs:      configs,
+		network:      networkConfig,
+		warnOnly:     ctx.Bool(config.ChainIDWarnOnly),
+	}
+	// listed by the API until they are started
+	scoutup.holdBack(results, pending)
+	if path := ctx.String(config.ConfigFile); path != "" && !ctx.Bool(config.Supersim) {
+		scoutup.reloadConfig = func(reloadCtx context.Context) (*config.NetworkConfig, error) {
+			return reloadNetworkConfig(reloadCtx, ctx, log, path)
+		}
+	}
+	return scoutup, nil
+}
+
+// applyFlags sets the network settings given by the flags.
+func applyFlags(ctx *cli.Context, networkConfig *config.NetworkConfig) {
 	networkConfig.StartingFrontendPort = ctx.Uint64(config.StartingFrontendPort)
 	networkConfig.StartingBackendPort = ctx.Uint64(config.StartingBackendPort)
 	networkConfig.StartingPostgresPort = ctx.Uint64(config.StartingPostgresPort)
+	networkConfig.ShutdownTimeout = ctx.Duration(config.ShutdownTimeout)
+	if ctx.IsSet(config.APIAddr) {
+		networkConfig.APIListenAddr = ctx.String(config.APIAddr)
+	}
+	if ctx.IsSet(config.AdminToken) {
+		networkConfig.AdminToken = ctx.String(config.AdminToken)
+	}
+	if ctx.IsSet(config.RewriteDockerHost) {
+		networkConfig.RewriteDockerHost = ctx.Bool(config.RewriteDockerHost)
+	}
+}
 
-	return blockscout.NewOrchestrator(log, closeApp, networkConfig.PrepareBlockscoutConfigs())
+// reloadNetworkConfig reads the config file again the way it is read on start,
+// except that --reindex is not applied to the reloaded chains.
+func reloadNetworkConfig(reloadCtx context.Context, ctx *cli.Context, log log.Logger, path string) (*config.NetworkConfig, error) {
+	networkConfig, err := config.LoadNetworkConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.ApplyEnvOverrides(networkConfig); err != nil {
+		return nil, fmt.Errorf("cannot apply environment overrides: %w", err)
+	}
+	applyFlags(ctx, networkConfig)
+	networkConfig.RewriteDockerHosts(log)
+	if err := networkConfig.VerifyChainIDs(reloadCtx, log, ctx.Bool(config.ChainIDWarnOnly)); err != nil {
+		return nil, fmt.Errorf("cannot verify chain ids: %w", err)
+	}
+	if err := networkConfig.ResolveFirstBlocks(reloadCtx, log); err != nil {
+		return nil, fmt.Errorf("cannot resolve first blocks: %w", err)
+	}
+	networkConfig.VerifyTracing(reloadCtx, log)
+	return networkConfig, nil
 }
 
 func ScoutupClean(ctx *cli.Context) error {
-	log := oplog.NewLogger(oplog.AppOut(ctx), oplog.DefaultCLIConfig())
+	log := newLogger(ctx, nil)
 	return blockscout.CleanupGlobalWorkspace(log)
 }
+
+// newLogger also sets up the global logger, used by geth internals.
+func newLogger(ctx *cli.Context, networkConfig *config.NetworkConfig) log.Logger {
+	handler := oplog.NewLogHandler(oplog.AppOut(ctx), networkConfig.LogConfig(ctx))
+	oplog.SetGlobalLogHandler(handler)
+	return log.NewLogger(handler)
+}
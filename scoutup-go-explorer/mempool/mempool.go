@@ -0,0 +1,233 @@
+package mempool
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/blockscout/scoutup/config"
+	"github.com/blockscout/scoutup/rpcclient"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const pollInterval = 2 * time.Second
+
+const (
+	// executable, waiting to be mined
+	StatusPending = "pending"
+	// waiting for a lower nonce of the sender
+	StatusQueued = "queued"
+	// no longer in the txpool and not mined, listed until the TTL expires
+	StatusDropped = "dropped"
+)
+
+// Tx is a transaction of the node's txpool.
+type Tx struct {
+	Hash                 common.Hash
+	From                 common.Address
+	To                   *common.Address
+	Nonce                uint64
+	Value                *big.Int
+	Gas                  uint64
+	GasPrice             *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	Type                 uint64
+	Input                []byte
+	Status               string
+	FirstSeen            time.Time
+	LastSeen             time.Time
+}
+
+type rpcTx struct {
+	Hash                 common.Hash     `json:"hash"`
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Value                *hexutil.Big    `json:"value"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Type                 hexutil.Uint64  `json:"type"`
+	Input                hexutil.Bytes   `json:"input"`
+}
+
+// txpool_content groups the transactions by sender and nonce
+type txpoolContent struct {
+	Pending map[common.Address]map[string]*rpcTx `json:"pending"`
+	Queued  map[common.Address]map[string]*rpcTx `json:"queued"`
+}
+
+// Watcher polls txpool_content of a chain's node and keeps its transactions
+// until they are mined, or until the TTL expires for the ones dropped from the
+// txpool without being mined.
+type Watcher struct {
+	chain *config.BlockscoutConfig
+	log   log.Logger
+	ttl   time.Duration
+
+	client *rpcclient.Client
+
+	mu  sync.RWMutex
+	txs map[common.Hash]*Tx
+}
+
+func New(log log.Logger, chain *config.BlockscoutConfig) *Watcher {
+	return &Watcher{
+		chain: chain,
+		log:   chain.Logger(log),
+		ttl:   chain.MempoolTTLOrDefault(),
+		txs:   make(map[common.Hash]*Tx),
+	}
+}
+
+// Run polls the node until ctx is cancelled or the node turns out not to
+// serve txpool_content.
+func (w *Watcher) Run(ctx context.Context) {
+	defer func() {
+		if w.client != nil {
+			w.client.Close()
+		}
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		err := w.poll(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case rpcclient.IsMethodNotFound(err):
+			w.log.Warn("Node does not support txpool_content, pending transactions are not tracked", "err", err)
+			return
+		case err != nil:
+			w.log.Debug("Cannot poll the txpool", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Pending returns the tracked transactions, the most recently seen first.
+func (w *Watcher) Pending() []*Tx {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	txs := make([]*Tx, 0, len(w.txs))
+	for _, tx := range w.txs {
+		copied := *tx
+		txs = append(txs, &copied)
+	}
+	slices.SortFunc(txs, func(a, b *Tx) int {
+		if c := b.FirstSeen.Compare(a.FirstSeen); c != 0 {
+			return c
+		}
+		return a.Hash.Cmp(b.Hash)
+	})
+	return txs
+}
+
+func (w *Watcher) poll(ctx context.Context) error {
+	if w.client == nil {
+		client, err := w.chain.DialRPC(ctx, w.log)
+		if err != nil {
+			return err
+		}
+		w.client = client
+	}
+
+	var content txpoolContent
+	if err := w.client.CallContext(ctx, &content, "txpool_content"); err != nil {
+		return err
+	}
+	now := time.Now()
+	seen := make(map[common.Hash]bool)
+	w.mu.Lock()
+	for status, senders := range map[string]map[common.Address]map[string]*rpcTx{StatusPending: content.Pending, StatusQueued: content.Queued} {
+		for _, txs := range senders {
+			for _, tx := range txs {
+				seen[tx.Hash] = true
+				w.update(tx, status, now)
+			}
+		}
+	}
+	var gone []common.Hash
+	for hash, tx := range w.txs {
+		if seen[hash] {
+			continue
+		}
+		if tx.Status == StatusDropped && now.Sub(tx.LastSeen) > w.ttl {
+			delete(w.txs, hash)
+			continue
+		}
+		gone = append(gone, hash)
+	}
+	w.mu.Unlock()
+
+	return w.pruneMined(ctx, gone)
+}
+
+func (w *Watcher) update(tx *rpcTx, status string, now time.Time) {
+	known, ok := w.txs[tx.Hash]
+	if !ok {
+		known = &Tx{
+			Hash:                 tx.Hash,
+			From:                 tx.From,
+			To:                   tx.To,
+			Nonce:                uint64(tx.Nonce),
+			Value:                tx.Value.ToInt(),
+			Gas:                  uint64(tx.Gas),
+			GasPrice:             tx.GasPrice.ToInt(),
+			MaxFeePerGas:         tx.MaxFeePerGas.ToInt(),
+			MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas.ToInt(),
+			Type:                 uint64(tx.Type),
+			Input:                tx.Input,
+			FirstSeen:            now,
+		}
+		w.txs[tx.Hash] = known
+	}
+	known.Status = status
+	known.LastSeen = now
+}
+
+// pruneMined removes the transactions no longer in the txpool that have a
+// receipt, the others are marked as dropped.
+func (w *Watcher) pruneMined(ctx context.Context, hashes []common.Hash) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	receipts := make([]*struct {
+		BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	}, len(hashes))
+	batch := make([]rpc.BatchElem, len(hashes))
+	for i, hash := range hashes {
+		batch[i] = rpc.BatchElem{Method: "eth_getTransactionReceipt", Args: []interface{}{hash}, Result: &receipts[i]}
+	}
+	if err := w.client.BatchCallContext(ctx, batch); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, elem := range batch {
+		tx, ok := w.txs[hashes[i]]
+		if !ok || elem.Error != nil {
+			continue
+		}
+		if receipts[i] != nil {
+			delete(w.txs, hashes[i])
+		} else {
+			tx.Status = StatusDropped
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "scoutup"
+
+var chainLabels = []string{"chain_id", "chain"}
+
+var (
+	registry = prometheus.NewRegistry()
+
+	nodeHead = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_head",
+		Help:      "Latest block number reported by the chain's node",
+	}, chainLabels)
+	indexedHeight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "indexed_height",
+		Help:      "Latest block number indexed by Blockscout",
+	}, chainLabels)
+	indexingLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "indexing_lag_blocks",
+		Help:      "Number of blocks the indexed height is behind the node head",
+	}, chainLabels)
+	blocksIndexed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "blocks_indexed_total",
+		Help:      "Number of blocks the indexed height advanced by",
+	}, chainLabels)
+	rpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_requests_total",
+		Help:      "Number of RPC requests made by scoutup",
+	}, append(chainLabels, "method"))
+	rpcErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Number of RPC requests made by scoutup that failed, after retries",
+	}, append(chainLabels, "method"))
+	reorgs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reorgs_total",
+		Help:      "Number of chain reorgs detected",
+	}, chainLabels)
+	reorgDepth = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reorg_depth_blocks",
+		Help:      "Depth of the detected chain reorgs",
+		Buckets:   []float64{1, 2, 4, 8, 16, 32, 64, 128},
+	}, chainLabels)
+	rejectedBlocks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rejected_blocks_total",
+		Help:      "Number of blocks served by the node that failed the hash verification",
+	}, chainLabels)
+	blockGaps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "block_gaps",
+		Help:      "Number of gaps found in the indexed blocks that are not refetched yet",
+	}, chainLabels)
+	blockGapsFilled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "block_gaps_filled_total",
+		Help:      "Number of gaps found in the indexed blocks that were refetched",
+	}, chainLabels)
+	cacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_cache_hits_total",
+		Help:      "Number of API lookups served from the cache",
+	}, append(chainLabels, "kind"))
+	cacheMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_cache_misses_total",
+		Help:      "Number of API lookups requested from Blockscout",
+	}, append(chainLabels, "kind"))
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		nodeHead, indexedHeight, indexingLag, blocksIndexed,
+		rpcRequests, rpcErrors, reorgs, reorgDepth, rejectedBlocks,
+		blockGaps, blockGapsFilled,
+		cacheHits, cacheMisses,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Chain records the metrics of a single chain. A nil Chain records nothing.
+type Chain struct {
+	labels prometheus.Labels
+
+	head    uint64
+	indexed uint64
+}
+
+func ForChain(chainID uint64, name string) *Chain {
+	return &Chain{labels: prometheus.Labels{"chain_id": strconv.FormatUint(chainID, 10), "chain": name}}
+}
+
+func (c *Chain) SetNodeHead(head uint64) {
+	if c == nil {
+		return
+	}
+	c.head = head
+	nodeHead.With(c.labels).Set(float64(head))
+	c.updateLag()
+}
+
+func (c *Chain) SetIndexedHeight(height uint64) {
+	if c == nil {
+		return
+	}
+	if c.indexed != 0 && height > c.indexed {
+		blocksIndexed.With(c.labels).Add(float64(height - c.indexed))
+	}
+	c.indexed = height
+	indexedHeight.With(c.labels).Set(float64(height))
+	c.updateLag()
+}
+
+func (c *Chain) updateLag() {
+	if c.head == 0 || c.indexed == 0 {
+		return
+	}
+	lag := uint64(0)
+	if c.head > c.indexed {
+		lag = c.head - c.indexed
+	}
+	indexingLag.With(c.labels).Set(float64(lag))
+}
+
+// RPCRequest counts an RPC request, err being its final outcome.
+func (c *Chain) RPCRequest(method string, err error) {
+	if c == nil {
+		return
+	}
+	labels := prometheus.Labels{"chain_id": c.labels["chain_id"], "chain": c.labels["chain"], "method": method}
+	rpcRequests.With(labels).Inc()
+	if err != nil {
+		rpcErrors.With(labels).Inc()
+	}
+}
+
+func (c *Chain) Reorg(depth uint64) {
+	if c == nil {
+		return
+	}
+	reorgs.With(c.labels).Inc()
+	reorgDepth.With(c.labels).Observe(float64(depth))
+}
+
+func (c *Chain) BlockRejected() {
+	if c == nil {
+		return
+	}
+	rejectedBlocks.With(c.labels).Inc()
+}
+
+func (c *Chain) SetBlockGaps(count int) {
+	if c == nil {
+		return
+	}
+	blockGaps.With(c.labels).Set(float64(count))
+}
+
+func (c *Chain) BlockGapsFilled(count int) {
+	if c == nil || count == 0 {
+		return
+	}
+	blockGapsFilled.With(c.labels).Add(float64(count))
+}
+
+// CacheLookup counts a lookup of kind (block, transaction) in the API cache.
+func (c *Chain) CacheLookup(kind string, hit bool) {
+	if c == nil {
+		return
+	}
+	labels := prometheus.Labels{"chain_id": c.labels["chain_id"], "chain": c.labels["chain"], "kind": kind}
+	if hit {
+		cacheHits.With(labels).Inc()
+	} else {
+		cacheMisses.With(labels).Inc()
+	}
+}
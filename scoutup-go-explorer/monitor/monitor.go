@@ -0,0 +1,190 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/blockscout/scoutup/config"
+	"github.com/blockscout/scoutup/metrics"
+	"github.com/blockscout/scoutup/rpcclient"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	backendTimeout = 5 * time.Second
+	// the indexed blocks are logged in batches, at most once per interval
+	progressLogInterval = 30 * time.Second
+	// the polls failing in a row wait longer and longer, up to the max
+	initialPollBackoff = time.Second
+	maxPollBackoff     = 30 * time.Second
+)
+
+// ReorgHandler is called with the lowest block height replaced by a reorg.
+type ReorgHandler func(chainID, from uint64)
+
+// RewindHandler is called with the common ancestor of a reorg and the node
+// head, for the orphaned blocks above the ancestor to be refetched.
+type RewindHandler func(ctx context.Context, ancestor, head uint64) error
+
+// SkipHandler is called with the height of a block skipped because its RPC
+// response is too large, for it to be refetched.
+type SkipHandler func(ctx context.Context, number uint64)
+
+// RejectHandler is called with the error of a block failing the verification
+// of its hash, and with nil once the node serves valid blocks again.
+type RejectHandler func(err error)
+
+// Handlers are called on the events of the followed chain, any of them may be nil.
+type Handlers struct {
+	Reorg  ReorgHandler
+	Rewind RewindHandler
+	Skip   SkipHandler
+	Reject RejectHandler
+}
+
+// Monitor follows the head of a chain's node while its Blockscout instance is
+// running and keeps track of the chain reorgs and of the indexing progress.
+type Monitor struct {
+	chain    *config.BlockscoutConfig
+	log      log.Logger
+	metrics  *metrics.Chain
+	handlers Handlers
+
+	client  *rpcclient.Client
+	backend *http.Client
+	// hashes of the recent canonical blocks, at most ReorgDepth of them
+	hashes      *headerWindow
+	unreachable bool
+	// the last verification failure, logged once
+	lastRejected string
+
+	// indexed height as of the last progress log
+	loggedHeight uint64
+	loggedAt     time.Time
+}
+
+// New returns a monitor of the chain calling handlers on its events.
+func New(log log.Logger, chain *config.BlockscoutConfig, handlers Handlers) *Monitor {
+	return &Monitor{
+		chain:    chain,
+		log:      chain.Logger(log),
+		metrics:  metrics.ForChain(chain.ChainID, chain.Name),
+		handlers: handlers,
+		backend:  &http.Client{Timeout: backendTimeout},
+		hashes:   newHeaderWindow(chain.ReorgDepthOrDefault()),
+	}
+}
+
+// Run polls the node until ctx is cancelled.
+func (m *Monitor) Run(ctx context.Context) {
+	defer func() {
+		if m.client != nil {
+			m.client.Close()
+		}
+	}()
+
+	// a zero interval polls again right away, the rate limiter of the RPC
+	// client holding the polls back
+	interval := m.chain.PollIntervalOrDefault()
+	backoff := time.Duration(0)
+	for {
+		if m.poll(ctx) {
+			backoff = 0
+		} else {
+			backoff = min(max(backoff*2, initialPollBackoff), maxPollBackoff)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(max(interval, backoff)):
+		}
+	}
+}
+
+// poll reports whether the node could be reached.
+func (m *Monitor) poll(ctx context.Context) bool {
+	if m.client == nil {
+		client, err := m.chain.DialRPC(ctx, m.log)
+		if err != nil {
+			m.setReachable(false, err)
+			return false
+		}
+		m.client = client
+	}
+
+	head, err := m.blockNumber(ctx)
+	if err == nil {
+		m.metrics.SetNodeHead(head)
+		err = m.followHead(ctx, head)
+	}
+	if errors.Is(err, errInvalidBlock) {
+		// the node answered, with a block that is not trusted
+		m.rejected(err)
+		err = nil
+	} else if err == nil {
+		m.accepted()
+	}
+	reachable := err == nil || ctx.Err() != nil
+	m.setReachable(reachable, err)
+
+	if height, err := m.indexedHeight(ctx); err != nil {
+		m.log.Debug("Cannot get the indexed height", "err", err)
+	} else {
+		m.metrics.SetIndexedHeight(height)
+		m.logProgress(height)
+	}
+	return reachable
+}
+
+func (m *Monitor) logProgress(height uint64) {
+	switch {
+	case m.loggedAt.IsZero() || height < m.loggedHeight:
+		m.loggedHeight, m.loggedAt = height, time.Now()
+	case height > m.loggedHeight && time.Since(m.loggedAt) >= progressLogInterval:
+		m.log.Info("Indexed blocks", "from", m.loggedHeight+1, "to", height, "count", height-m.loggedHeight)
+		m.loggedHeight, m.loggedAt = height, time.Now()
+	}
+}
+
+// indexedHeight returns the latest block indexed by Blockscout.
+func (m *Monitor) indexedHeight(ctx context.Context) (uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.chain.BackendURL()+"/api/v2/main-page/blocks", nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := m.backend.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("blockscout responded with %s", resp.Status)
+	}
+
+	var blocks []struct {
+		Height uint64 `json:"height"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&blocks); err != nil {
+		return 0, err
+	}
+	height := uint64(0)
+	for _, block := range blocks {
+		height = max(height, block.Height)
+	}
+	return height, nil
+}
+
+// setReachable logs the node reachability changes only, not every failed poll
+func (m *Monitor) setReachable(reachable bool, err error) {
+	switch {
+	case !reachable && !m.unreachable:
+		m.log.Warn("Chain RPC is unreachable", "err", err)
+	case reachable && m.unreachable:
+		m.log.Info("Chain RPC is reachable again")
+	}
+	m.unreachable = !reachable
+}
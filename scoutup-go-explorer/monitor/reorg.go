@@ -0,0 +1,251 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/blockscout/scoutup/rpcclient"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type header struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	// the header as returned by the node
+	raw json.RawMessage
+}
+
+// headerWindow keeps the canonical hashes of the last size blocks.
+type headerWindow struct {
+	size   uint64
+	hashes map[uint64]common.Hash
+	tip    uint64
+}
+
+func newHeaderWindow(size uint64) *headerWindow {
+	return &headerWindow{size: size, hashes: make(map[uint64]common.Hash)}
+}
+
+func (w *headerWindow) get(number uint64) (common.Hash, bool) {
+	hash, ok := w.hashes[number]
+	return hash, ok
+}
+
+func (w *headerWindow) empty() bool {
+	return len(w.hashes) == 0
+}
+
+func (w *headerWindow) put(number uint64, hash common.Hash) {
+	w.hashes[number] = hash
+	if number > w.tip || len(w.hashes) == 1 {
+		w.tip = number
+	}
+	for n := range w.hashes {
+		if n+w.size <= w.tip {
+			delete(w.hashes, n)
+		}
+	}
+}
+
+// truncate forgets the blocks above number, e.g. when the chain got shorter
+func (w *headerWindow) truncate(number uint64) {
+	for n := range w.hashes {
+		if n > number {
+			delete(w.hashes, n)
+		}
+	}
+	w.tip = min(w.tip, number)
+}
+
+func (w *headerWindow) reset() {
+	clear(w.hashes)
+	w.tip = 0
+}
+
+// followHead walks from the last seen block up to head checking that every
+// block links to its parent. On a mismatch it walks back to the common
+// ancestor, which must be within ReorgDepth blocks.
+func (m *Monitor) followHead(ctx context.Context, head uint64) error {
+	w := m.hashes
+	if w.empty() || head+w.size < w.tip || head > w.tip+w.size {
+		// nothing to compare against, or too far away to be worth walking
+		return m.fill(ctx, head)
+	}
+
+	// the tip itself may have been replaced by a block at the same height
+	from := min(w.tip, head)
+	w.truncate(head)
+	for number := from; number <= head; number++ {
+		h, err := m.header(ctx, number)
+		if errors.Is(err, rpcclient.ErrResponseTooLarge) {
+			// the block is left unchecked rather than stalling the monitor,
+			// the next one has no parent to be compared with
+			m.skipped(ctx, number, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		known, ok := w.get(number)
+		if number == from && ok && known != h.Hash {
+			if err := m.rewind(ctx, number, head); err != nil {
+				return err
+			}
+		} else if parent, ok := w.get(number - 1); ok && parent != h.ParentHash {
+			if err := m.rewind(ctx, number-1, head); err != nil {
+				return err
+			}
+			// the canonical parent found walking back must be the one linked to
+			if parent, ok := w.get(number - 1); m.chain.VerifyBlockHashes && ok && parent != h.ParentHash {
+				return verifyParent(h, &header{Number: hexutil.Uint64(number - 1), Hash: parent})
+			}
+		}
+		w.put(number, h.Hash)
+	}
+	return nil
+}
+
+// rewind walks back from the orphaned block at number to the common ancestor
+// replacing the orphaned hashes with the canonical ones, then has the blocks
+// above the ancestor refetched up to head.
+func (m *Monitor) rewind(ctx context.Context, number, head uint64) error {
+	w := m.hashes
+	for n := number; n+w.size > number; n-- {
+		known, ok := w.get(n)
+		if !ok {
+			break
+		}
+		h, err := m.header(ctx, n)
+		if err != nil {
+			return err
+		}
+		if h.Hash == known {
+			depth := number - n
+			m.metrics.Reorg(depth)
+			m.reorged(n + 1)
+			m.rewound(ctx, depth, n, head)
+			return nil
+		}
+		w.hashes[n] = h.Hash
+		if n == 0 {
+			break
+		}
+	}
+
+	m.metrics.Reorg(w.size)
+	m.reorged(number - min(number, w.size))
+	m.log.Error("Chain reorg is deeper than the configured reorg depth, giving up on tracking it. Blockscout data may stay stale, consider reindexing",
+		"reorgDepth", w.size, "orphaned", number)
+	return m.fill(ctx, number)
+}
+
+// rewound has the orphaned blocks above the common ancestor refetched. A
+// failure is logged only, the hashes followed are the canonical ones already.
+func (m *Monitor) rewound(ctx context.Context, depth, ancestor, head uint64) {
+	if m.handlers.Rewind == nil {
+		m.log.Warn("Chain reorg detected, the orphaned blocks are left to Blockscout", "depth", depth, "commonAncestor", ancestor)
+		return
+	}
+	if err := m.handlers.Rewind(ctx, ancestor, head); err != nil {
+		m.log.Error("Chain reorg detected, cannot rewind the orphaned blocks", "depth", depth, "commonAncestor", ancestor, "err", err)
+		return
+	}
+	m.log.Warn("Chain reorg detected, marked the orphaned blocks as non-consensus and queued them for refetching",
+		"depth", depth, "commonAncestor", ancestor, "head", head)
+}
+
+func (m *Monitor) skipped(ctx context.Context, number uint64, err error) {
+	m.log.Warn("Skipping block with an oversized RPC response", "number", number, "err", err)
+	if m.handlers.Skip != nil {
+		m.handlers.Skip(ctx, number)
+	}
+}
+
+func (m *Monitor) reorged(from uint64) {
+	if m.handlers.Reorg != nil {
+		m.handlers.Reorg(m.chain.ChainID, from)
+	}
+}
+
+// fill replaces the known hashes with the last ReorgDepth blocks up to head.
+func (m *Monitor) fill(ctx context.Context, head uint64) error {
+	w := m.hashes
+	from := uint64(0)
+	if head >= w.size {
+		from = head - w.size + 1
+	}
+
+	headers := make([]*header, head-from+1)
+	batch := make([]rpc.BatchElem, len(headers))
+	for i := range batch {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.Uint64(from + uint64(i)), false},
+			Result: &headers[i],
+		}
+	}
+	if err := m.client.BatchCallContext(ctx, batch); err != nil {
+		return err
+	}
+
+	for i, elem := range batch {
+		if errors.Is(elem.Error, rpcclient.ErrResponseTooLarge) {
+			m.skipped(ctx, from+uint64(i), elem.Error)
+			continue
+		}
+		if elem.Error != nil {
+			return elem.Error
+		}
+		if headers[i] == nil {
+			return fmt.Errorf("block %d not found", from+uint64(i))
+		}
+		if m.chain.VerifyBlockHashes {
+			if err := verifyHash(headers[i]); err != nil {
+				return err
+			}
+			if i > 0 && headers[i-1] != nil {
+				if err := verifyParent(headers[i], headers[i-1]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	w.reset()
+	for _, h := range headers {
+		if h != nil {
+			w.put(uint64(h.Number), h.Hash)
+		}
+	}
+	return nil
+}
+
+func (m *Monitor) blockNumber(ctx context.Context) (uint64, error) {
+	var number hexutil.Uint64
+	if err := m.client.CallContext(ctx, &number, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return uint64(number), nil
+}
+
+func (m *Monitor) header(ctx context.Context, number uint64) (*header, error) {
+	var h *header
+	if err := m.client.CallContext(ctx, &h, "eth_getBlockByNumber", hexutil.Uint64(number), false); err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	if m.chain.VerifyBlockHashes {
+		if err := verifyHash(h); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
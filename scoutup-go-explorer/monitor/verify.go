@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// errInvalidBlock is returned for a block whose hash does not match its header
+// fields or whose parentHash does not link it to the previous block.
+var errInvalidBlock = errors.New("invalid block")
+
+// the fields of a block returned by the node that are not part of its header
+var nonHeaderFields = []string{"hash", "size", "totalDifficulty", "transactions", "uncles", "withdrawals"}
+
+// UnmarshalJSON keeps the header as returned by the node, for verifying its hash.
+func (h *header) UnmarshalJSON(data []byte) error {
+	type fields header
+	if err := json.Unmarshal(data, (*fields)(h)); err != nil {
+		return err
+	}
+	h.raw = slices.Clone(data)
+	return nil
+}
+
+// verifyHash recomputes the hash of the block from its RLP encoded header.
+func verifyHash(h *header) error {
+	var full types.Header
+	if err := json.Unmarshal(h.raw, &full); err != nil {
+		return fmt.Errorf("%w %d: cannot decode the header: %w", errInvalidBlock, h.Number, err)
+	}
+	if computed := full.Hash(); computed != h.Hash {
+		if field, err := mismatchedField(h.raw, &full); err != nil {
+			return fmt.Errorf("%w %d: hash %s does not match %s computed from the header fields: %w", errInvalidBlock, h.Number, h.Hash, computed, err)
+		} else if field != "" {
+			return fmt.Errorf("%w %d: hash %s does not match %s computed from the header fields, %s", errInvalidBlock, h.Number, h.Hash, computed, field)
+		}
+		return fmt.Errorf("%w %d: hash %s does not match %s computed from the header fields", errInvalidBlock, h.Number, h.Hash, computed)
+	}
+	return nil
+}
+
+// mismatchedField compares the header fields returned by the node with the
+// decoded header encoded again, and describes the first one that is unknown
+// to the header or changed by decoding it. When every field survives, the
+// hash itself is the mismatched field and an empty string is returned.
+func mismatchedField(raw json.RawMessage, full *types.Header) (string, error) {
+	encoded, err := json.Marshal(full)
+	if err != nil {
+		return "", err
+	}
+	var served, decoded map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &served); err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return "", err
+	}
+	for _, field := range slices.Sorted(maps.Keys(served)) {
+		if slices.Contains(nonHeaderFields, field) {
+			continue
+		}
+		value, ok := decoded[field]
+		switch {
+		case (!ok || string(value) == "null") && string(served[field]) != "null":
+			return fmt.Sprintf("field %s is not part of the header hashed by geth", field), nil
+		case ok && !strings.EqualFold(string(value), string(served[field])):
+			return fmt.Sprintf("field %s %s is decoded as %s", field, served[field], value), nil
+		}
+	}
+	return "", nil
+}
+
+// verifyParent checks that the block links to the given hash of its parent.
+func verifyParent(h *header, parent *header) error {
+	if h.ParentHash != parent.Hash {
+		return fmt.Errorf("%w %d: parentHash %s does not match hash %s of block %d", errInvalidBlock, h.Number, h.ParentHash, parent.Hash, parent.Number)
+	}
+	return nil
+}
+
+// rejected logs the blocks failing the verification, the same failure once.
+func (m *Monitor) rejected(err error) {
+	if err.Error() == m.lastRejected {
+		return
+	}
+	m.lastRejected = err.Error()
+	m.metrics.BlockRejected()
+	m.log.Error("Rejected block served by the node, it is not followed until the node serves a valid one", "err", err)
+	if m.handlers.Reject != nil {
+		m.handlers.Reject(err)
+	}
+}
+
+// accepted clears the last verification failure once valid blocks are followed again.
+func (m *Monitor) accepted() {
+	if m.lastRejected == "" {
+		return
+	}
+	m.lastRejected = ""
+	m.log.Info("Node serves valid blocks again")
+	if m.handlers.Reject != nil {
+		m.handlers.Reject(nil)
+	}
+}
@@ -0,0 +1,95 @@
+package rpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BatchCallContext sends the requests in batches of at most Options.BatchSize
+// elements. Per-request errors are reported through the Error field of the
+// elements. Nodes that do not support batches are called one request at a time
+// from then on.
+func (c *Client) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	if c.batchUnsupported.Load() {
+		return c.callEach(ctx, b)
+	}
+
+	size := c.opts.BatchSize
+	if size <= 0 {
+		size = len(b)
+	}
+	for start := 0; start < len(b); start += size {
+		chunk := b[start:min(start+size, len(b))]
+		begin := time.Now()
+		err := c.withRetry(ctx, "batch", func() error {
+			if err := c.throttle(ctx, len(chunk)); err != nil {
+				return err
+			}
+			return c.withFailover(ctx, "batch", func(client *rpc.Client) error {
+				return client.BatchCallContext(ctx, chunk)
+			})
+		})
+		c.log.Debug("RPC batch call", "size", len(chunk), "duration", time.Since(begin), "err", err)
+		if err == nil && !allMissing(chunk) {
+			for _, elem := range chunk {
+				c.opts.Metrics.RPCRequest(elem.Method, elem.Error)
+			}
+			continue
+		}
+		if errors.Is(err, ErrResponseTooLarge) && len(chunk) > 1 {
+			// the requests fitting on their own still succeed, only the
+			// oversized ones fail
+			c.log.Warn("RPC batch response is too large, calling its requests one at a time", "size", len(chunk), "maxResponseSize", c.opts.MaxResponseSize)
+			if err := c.callEach(ctx, chunk); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil && (ctx.Err() != nil || !isBatchUnsupported(err)) {
+			for _, elem := range chunk {
+				c.opts.Metrics.RPCRequest(elem.Method, err)
+			}
+			return err
+		}
+
+		c.log.Warn("RPC does not support batch requests, falling back to individual calls", "url", c.URL(), "err", err)
+		c.batchUnsupported.Store(true)
+		return c.callEach(ctx, b[start:])
+	}
+	return nil
+}
+
+func (c *Client) callEach(ctx context.Context, b []rpc.BatchElem) error {
+	for i := range b {
+		b[i].Error = c.CallContext(ctx, b[i].Result, b[i].Method, b[i].Args...)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// allMissing reports whether the node answered the batch without a single
+// matching response, some nodes do that instead of rejecting batches.
+func allMissing(b []rpc.BatchElem) bool {
+	for _, elem := range b {
+		if !errors.Is(elem.Error, rpc.ErrMissingBatchResponse) {
+			return false
+		}
+	}
+	return len(b) > 0
+}
+
+func isBatchUnsupported(err error) bool {
+	// a single error object returned instead of an array of responses
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return true
+	}
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32600
+}
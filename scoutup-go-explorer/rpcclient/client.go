@@ -0,0 +1,231 @@
+package rpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/blockscout/scoutup/metrics"
+	"github.com/blockscout/scoutup/utils"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
+)
+
+// Client is a JSON-RPC client over one or more endpoints of the same chain.
+// When a call fails with a connection error it fails over to the next
+// endpoint and keeps using it for the following calls.
+type Client struct {
+	log  log.Logger
+	urls []string
+	opts Options
+
+	batchUnsupported atomic.Bool
+
+	mu      sync.Mutex
+	current int
+	clients []*rpc.Client
+}
+
+type Options struct {
+	// Maximum number of requests sent in a single batch, unlimited when zero
+	BatchSize int
+	// Maximum number of retries of idempotent calls failing with transient errors
+	MaxRetries int
+	// Largest response read in bytes, unlimited when zero
+	MaxResponseSize int64
+	// Records the requests, nothing is recorded when nil
+	Metrics *metrics.Chain
+	// Throttles the requests, possibly shared with other clients. Unlimited when nil.
+	Limiter *rate.Limiter
+	// Sent with every request, basic auth credentials can be embedded in the urls instead
+	Headers http.Header
+}
+
+func Dial(ctx context.Context, log log.Logger, urls []string, opts Options) (*Client, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no rpc urls provided")
+	}
+	c := &Client{
+		log:     log,
+		urls:    urls,
+		opts:    opts,
+		clients: make([]*rpc.Client, len(urls)),
+	}
+
+	// With a single endpoint there is nothing to fail over to,
+	// so it is dialed right away and used directly by CallContext.
+	if len(urls) == 1 {
+		client, err := c.dial(ctx, urls[0])
+		if err != nil {
+			return nil, err
+		}
+		c.clients[0] = client
+	}
+	return c, nil
+}
+
+// CallContext performs a JSON-RPC call, failing over across the endpoints on
+// connection errors and retrying transient failures.
+func (c *Client) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	start := time.Now()
+	err := c.withRetry(ctx, method, func() error {
+		if err := c.throttle(ctx, 1); err != nil {
+			return err
+		}
+		if len(c.clients) == 1 {
+			return c.clients[0].CallContext(ctx, result, method, args...)
+		}
+		return c.withFailover(ctx, method, func(client *rpc.Client) error {
+			return client.CallContext(ctx, result, method, args...)
+		})
+	})
+	c.opts.Metrics.RPCRequest(method, err)
+	c.log.Debug("RPC call", "method", method, "duration", time.Since(start), "err", err)
+	return err
+}
+
+// throttle blocks until n requests are allowed by the limiter.
+func (c *Client) throttle(ctx context.Context, n int) error {
+	if c.opts.Limiter == nil {
+		return nil
+	}
+	// WaitN rejects more requests than the burst at once
+	for burst := c.opts.Limiter.Burst(); n > 0; n -= burst {
+		if err := c.opts.Limiter.WaitN(ctx, min(n, burst)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withFailover runs fn against the current endpoint and then against the
+// following ones as long as they fail with connection errors.
+func (c *Client) withFailover(ctx context.Context, method string, fn func(*rpc.Client) error) error {
+	c.mu.Lock()
+	start := c.current
+	c.mu.Unlock()
+
+	var lastErr error
+	for attempt := 0; attempt < len(c.urls); attempt++ {
+		idx := (start + attempt) % len(c.urls)
+		client, err := c.client(ctx, idx)
+		if err == nil {
+			err = fn(client)
+		}
+		if err == nil {
+			c.setCurrent(idx)
+			return nil
+		}
+		if ctx.Err() != nil || !isConnectionError(err) {
+			return err
+		}
+
+		lastErr = err
+		next := (idx + 1) % len(c.urls)
+		if attempt+1 < len(c.urls) {
+			c.log.Warn("RPC endpoint is unreachable, failing over", "from", utils.RedactURL(c.urls[idx]), "to", utils.RedactURL(c.urls[next]), "method", method, "err", err)
+		}
+	}
+	return fmt.Errorf("all %d rpc endpoints failed: %w", len(c.urls), lastErr)
+}
+
+// URL returns the endpoint currently in use, without its password.
+func (c *Client) URL() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return utils.RedactURL(c.urls[c.current])
+}
+
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, client := range c.clients {
+		if client != nil {
+			client.Close()
+		}
+	}
+}
+
+func (c *Client) client(ctx context.Context, idx int) (*rpc.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clients[idx] == nil {
+		client, err := c.dial(ctx, c.urls[idx])
+		if err != nil {
+			return nil, err
+		}
+		c.clients[idx] = client
+	}
+	return c.clients[idx], nil
+}
+
+func (c *Client) dial(ctx context.Context, url string) (*rpc.Client, error) {
+	options := []rpc.ClientOption{rpc.WithHeaders(c.opts.Headers)}
+	if c.opts.MaxResponseSize > 0 {
+		options = append(options,
+			rpc.WithHTTPClient(&http.Client{Transport: &limitedTransport{base: http.DefaultTransport, limit: c.opts.MaxResponseSize}}),
+			rpc.WithWebsocketMessageSizeLimit(c.opts.MaxResponseSize))
+	}
+	client, err := rpc.DialOptions(ctx, url, options...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial %s: %w", utils.RedactURL(url), err)
+	}
+	return client, nil
+}
+
+func (c *Client) setCurrent(idx int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current != idx {
+		c.log.Info("Switched RPC endpoint", "url", utils.RedactURL(c.urls[idx]))
+		c.current = idx
+	}
+}
+
+// IsMethodNotFound reports whether the node does not serve the requested method.
+func IsMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	if rpcErr.ErrorCode() == -32601 {
+		return true
+	}
+	// not every node uses the standard code, e.g. for a disabled namespace
+	msg := strings.ToLower(rpcErr.Error())
+	return strings.Contains(msg, "method not found") ||
+		strings.Contains(msg, "does not exist") ||
+		strings.Contains(msg, "not supported")
+}
+
+// isConnectionError reports whether the endpoint itself could not serve the
+// request, as opposed to the node answering with a JSON-RPC error.
+func isConnectionError(err error) bool {
+	if errors.Is(err, ErrResponseTooLarge) {
+		// wrapped in a *url.Error, which is a net.Error, when the length is known up front
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
@@ -0,0 +1,55 @@
+package rpcclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrResponseTooLarge is returned for a response larger than
+// Options.MaxResponseSize, it is not retried.
+var ErrResponseTooLarge = errors.New("rpc response exceeds the maximum response size")
+
+// limitedTransport fails the responses larger than limit while they are read,
+// instead of buffering them whole.
+type limitedTransport struct {
+	base  http.RoundTripper
+	limit int64
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > t.limit {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d > %d bytes", ErrResponseTooLarge, resp.ContentLength, t.limit)
+	}
+	resp.Body = &limitedBody{body: resp.Body, remaining: t.limit}
+	return resp, nil
+}
+
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	// reading a byte past the limit tells a response of exactly the limit apart
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	if int64(n) > b.remaining {
+		b.remaining = 0
+		return 0, ErrResponseTooLarge
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
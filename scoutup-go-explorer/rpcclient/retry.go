@@ -0,0 +1,86 @@
+package rpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	initialRetryDelay = 200 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
+
+// methods with side effects that must never be sent twice
+var nonIdempotentMethods = map[string]bool{
+	"eth_sendRawTransaction": true,
+	"eth_sendTransaction":    true,
+}
+
+// withRetry runs fn until it succeeds, fails with a permanent error, or
+// Options.MaxRetries retries are used up. Delays grow exponentially with full jitter.
+func (c *Client) withRetry(ctx context.Context, method string, fn func() error) error {
+	if c.opts.MaxRetries <= 0 || nonIdempotentMethods[method] {
+		return fn()
+	}
+
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s failed after %d attempt(s): %w", method, attempt, err)
+		}
+		if !isRetryable(err) {
+			return fmt.Errorf("%s failed after %d attempt(s), not retryable: %w", method, attempt, err)
+		}
+		if attempt > c.opts.MaxRetries {
+			return fmt.Errorf("%s failed after %d attempts: %w", method, attempt, err)
+		}
+
+		wait := rand.N(delay) + 1
+		c.log.Debug("Retrying RPC request", "method", method, "attempt", attempt, "delay", wait, "err", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s failed after %d attempt(s): %w", method, attempt, err)
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// isRetryable reports whether err is likely transient: timeouts, dropped
+// connections, rate limiting and server side failures.
+func isRetryable(err error) bool {
+	if errors.Is(err, ErrResponseTooLarge) {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.ErrorCode() {
+		case -32603, // internal error
+			-32005: // limit exceeded
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return isConnectionError(err)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/blockscout/scoutup/api"
+	"github.com/blockscout/scoutup/blockscout"
+	"github.com/blockscout/scoutup/config"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const pendingRetryInterval = 30 * time.Second
+
+// Scoutup runs the Blockscout instances together with the API serving their data.
+type Scoutup struct {
+	log          log.Logger
+	orchestrator *blockscout.Orchestrator
+	api          *api.Server
+
+	// the running chains, changed by the config reloads
+	configs []*config.BlockscoutConfig
+	// reads the config file again, nil when scoutup was not started with one
+	reloadConfig func(ctx context.Context) (*config.NetworkConfig, error)
+	hup          chan os.Signal
+	stopReloads  context.CancelFunc
+
+	// the chains held back on start until their RPC answers, started on the
+	// ports following the ones of network
+	pending  []*config.ChainConfig
+	network  *config.NetworkConfig
+	warnOnly bool
+}
+
+func (s *Scoutup) Start(ctx context.Context) error {
+	if err := s.api.Start(ctx); err != nil {
+		return err
+	}
+	if err := s.orchestrator.Start(ctx); err != nil {
+		return errors.Join(err, s.api.Stop(ctx))
+	}
+
+	s.hup = make(chan os.Signal, 1)
+	signal.Notify(s.hup, syscall.SIGHUP)
+	reloadCtx, cancel := context.WithCancel(context.Background())
+	s.stopReloads = cancel
+	go s.handleReloads(reloadCtx)
+	return nil
+}
+
+func (s *Scoutup) Stop(ctx context.Context) error {
+	if s.stopReloads != nil {
+		signal.Stop(s.hup)
+		s.stopReloads()
+	}
+	return errors.Join(s.api.Stop(ctx), s.orchestrator.Stop(ctx))
+}
+
+// handleReloads reloads the TLS certificate and the config on every SIGHUP,
+// and retries the held back chains, until ctx is cancelled.
+func (s *Scoutup) handleReloads(ctx context.Context) {
+	for {
+		var retry <-chan time.Time
+		if len(s.pending) > 0 {
+			retry = time.After(pendingRetryInterval)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-retry:
+			s.startPending(ctx)
+			continue
+		case <-s.hup:
+		}
+
+		if err := s.api.ReloadCertificate(); err != nil {
+			s.log.Error("TLS certificate reload rejected, the previous one is kept", "err", err)
+		}
+		if s.reloadConfig == nil {
+			if !s.api.TLSEnabled() {
+				s.log.Warn("Ignoring SIGHUP, only a config file passed with --config can be reloaded")
+			}
+			continue
+		}
+		s.log.Info("Reloading config")
+		if err := s.reload(ctx); err != nil {
+			s.log.Error("Config reload rejected, the chains keep running unchanged", "err", err)
+		}
+	}
+}
+
+// reload starts the chains added to the config file and stops the removed
+// ones, the changed chains are restarted and the others are left running.
+func (s *Scoutup) reload(ctx context.Context) error {
+	networkConfig, err := s.reloadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	reload, err := networkConfig.PrepareReload(s.configs)
+	if err != nil {
+		return err
+	}
+	if reload.Empty() {
+		s.log.Info("Config reloaded, no chain changed")
+		return nil
+	}
+
+	var errs []error
+	for _, cfg := range append(reload.Removed, reload.Changed...) {
+		s.api.RemoveChain(cfg.ChainID)
+		if err := s.orchestrator.RemoveInstance(ctx, cfg.ChainID); err != nil {
+			errs = append(errs, err)
+		}
+		s.configs = removeConfig(s.configs, cfg.ChainID)
+	}
+
+	started := append(reload.Changed, reload.Added...)
+	if err := s.orchestrator.AddInstances(started); err != nil {
+		return errors.Join(append(errs, fmt.Errorf("cannot start the reloaded chains: %w", err))...)
+	}
+	for _, cfg := range started {
+		s.api.AddChain(cfg)
+		s.configs = append(s.configs, cfg)
+	}
+
+	// every configured chain answered for the reload to get here
+	s.holdBack(nil, nil)
+	s.log.Info("Config reloaded", "added", len(reload.Added), "removed", len(reload.Removed), "restarted", len(reload.Changed))
+	return errors.Join(errs...)
+}
+
+// startPending starts the held back chains whose RPC answers now, the others
+// are retried later.
+func (s *Scoutup) startPending(ctx context.Context) {
+	var reachable, unreachable []*config.ChainConfig
+	results := config.Preflight(ctx, s.log, s.pending)
+	for _, result := range results {
+		if result.Reachable() {
+			reachable = append(reachable, result.Chain)
+		} else {
+			unreachable = append(unreachable, result.Chain)
+		}
+	}
+	if len(reachable) == 0 {
+		s.holdBack(results, s.pending)
+		return
+	}
+
+	added, err := s.addChains(ctx, reachable)
+	if err != nil {
+		s.log.Error("Cannot start the chains whose RPC answers now, retrying later", "err", err)
+		return
+	}
+	s.holdBack(results, unreachable)
+	for _, cfg := range added {
+		s.log.Info("Chain RPC answers now, started the chain", "chain", cfg.Name, "chainID", cfg.ChainID)
+	}
+}
+
+// addChains verifies the chains the way they are verified on start and
+// starts them alongside the running ones.
+func (s *Scoutup) addChains(ctx context.Context, chains []*config.ChainConfig) ([]*config.BlockscoutConfig, error) {
+	network := *s.network
+	network.Chains = chains
+	if err := network.VerifyChainIDs(ctx, s.log, s.warnOnly); err != nil {
+		return nil, fmt.Errorf("cannot verify chain ids: %w", err)
+	}
+	if err := network.ResolveFirstBlocks(ctx, s.log); err != nil {
+		return nil, fmt.Errorf("cannot resolve first blocks: %w", err)
+	}
+	network.VerifyTracing(ctx, s.log)
+
+	// along with the running chains, so that their chain ids do not clash
+	for _, cfg := range s.configs {
+		network.Chains = append(network.Chains, cfg.ChainConfig)
+	}
+	if err := network.Validate(); err != nil {
+		return nil, err
+	}
+	reload, err := network.PrepareReload(s.configs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orchestrator.AddInstances(reload.Added); err != nil {
+		return nil, err
+	}
+	for _, cfg := range reload.Added {
+		s.api.AddChain(cfg)
+		s.configs = append(s.configs, cfg)
+	}
+	return reload.Added, nil
+}
+
+// holdBack keeps the chains pending, the API lists them as not started with the
+// error of their preflight.
+func (s *Scoutup) holdBack(results []*config.PreflightResult, pending []*config.ChainConfig) {
+	s.pending = pending
+	reasons := make(map[string]error, len(pending))
+	for _, result := range results {
+		if slices.Contains(pending, result.Chain) {
+			reasons[result.Chain.Name] = fmt.Errorf("RPC is unreachable: %w", result.Err)
+		}
+	}
+	s.api.SetPending(reasons)
+}
+
+func removeConfig(configs []*config.BlockscoutConfig, chainID uint64) []*config.BlockscoutConfig {
+	kept := configs[:0]
+	for _, cfg := range configs {
+		if cfg.ChainID != chainID {
+			kept = append(kept, cfg)
+		}
+	}
+	return kept
+}
+
+// no-op dead code in the cliapp lifecycle
+func (s *Scoutup) Stopped() bool {
+	return false
+}
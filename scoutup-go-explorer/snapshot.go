@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blockscout/scoutup/blockscout"
+	"github.com/blockscout/scoutup/config"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli/v2"
+)
+
+func ScoutupSnapshotExport(ctx *cli.Context) error {
+	log, chain, file, err := snapshotTarget(ctx)
+	if err != nil {
+		return err
+	}
+	metadata, err := blockscout.ExportSnapshot(ctx.Context, log, chain, file)
+	if err != nil {
+		return fmt.Errorf("cannot export %s: %w", chain.Name, err)
+	}
+	log.Info("Exported snapshot", "chain", chain.Name, "file", file, "indexedTip", metadata.IndexedTip)
+	return nil
+}
+
+func ScoutupSnapshotImport(ctx *cli.Context) error {
+	log, chain, file, err := snapshotTarget(ctx)
+	if err != nil {
+		return err
+	}
+	metadata, err := blockscout.ImportSnapshot(ctx.Context, log, chain, file)
+	if err != nil {
+		return fmt.Errorf("cannot import into %s: %w", chain.Name, err)
+	}
+	log.Info("Imported snapshot, indexing resumes from its tip", "chain", chain.Name, "indexedTip", metadata.IndexedTip)
+	return nil
+}
+
+// snapshotTarget loads the config the way it is loaded on start and returns
+// the chain selected with --chain, along with the snapshot file.
+func snapshotTarget(ctx *cli.Context) (log.Logger, *config.BlockscoutConfig, string, error) {
+	log := newLogger(ctx, nil)
+	if ctx.NArg() != 1 {
+		return nil, nil, "", errors.New("expected the snapshot file as the only argument")
+	}
+	file := ctx.Args().First()
+
+	var networkConfig *config.NetworkConfig
+	var err error
+	if ctx.Bool(config.Supersim) {
+		networkConfig, err = config.PrepareSupersimConfig(ctx.String(config.SupersimAdminRpc))
+	} else {
+		networkConfig, err = config.LoadNetworkConfig(ctx.String(config.ConfigFile))
+	}
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("cannot load network config: %w", err)
+	}
+	log = newLogger(ctx, networkConfig)
+	if err := config.ApplyEnvOverrides(networkConfig); err != nil {
+		return nil, nil, "", fmt.Errorf("cannot apply environment overrides: %w", err)
+	}
+	applyFlags(ctx, networkConfig)
+	networkConfig.RewriteDockerHosts(log)
+	// the node may be down, the check is skipped then for configured chain ids
+	if err := networkConfig.VerifyChainIDs(ctx.Context, log, ctx.Bool(config.ChainIDWarnOnly)); err != nil {
+		return nil, nil, "", fmt.Errorf("cannot verify chain ids: %w", err)
+	}
+
+	configs := networkConfig.PrepareBlockscoutConfigs()
+	name := ctx.String(config.Chain)
+	if name == "" && len(configs) == 1 {
+		return log, configs[0], file, nil
+	}
+	var names []string
+	for _, chain := range configs {
+		if chain.Name == name {
+			return log, chain, file, nil
+		}
+		names = append(names, chain.Name)
+	}
+	if name == "" {
+		return nil, nil, "", fmt.Errorf("select the chain with --%s, one of %s", config.Chain, strings.Join(names, ", "))
+	}
+	return nil, nil, "", fmt.Errorf("unknown chain %s, expected one of %s", name, strings.Join(names, ", "))
+}
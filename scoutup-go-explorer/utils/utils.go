@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 
@@ -56,3 +57,12 @@ func MakeGetRequest(url string) ([]byte, error) {
 
 	return io.ReadAll(resp.Body)
 }
+
+// RedactURL hides the password of the url, if any
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<malformed>"
+	}
+	return u.Redacted()
+}